@@ -1,13 +1,404 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	cityName "github.com/jddcode/tech-test-ennismore/internal/city-name"
+	coOrdinateFinder "github.com/jddcode/tech-test-ennismore/internal/co-ordinate-finder"
+	handlerAdmin "github.com/jddcode/tech-test-ennismore/internal/handler-admin"
+	handlerHealth "github.com/jddcode/tech-test-ennismore/internal/handler-health"
 	handlerWeather "github.com/jddcode/tech-test-ennismore/internal/handler-weather"
 	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/cache"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"github.com/jddcode/tech-test-ennismore/internal/place"
+	redisClient "github.com/jddcode/tech-test-ennismore/internal/redis-client"
+	"github.com/jddcode/tech-test-ennismore/internal/scheduler"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	weatherFetcher "github.com/jddcode/tech-test-ennismore/internal/weather-fetcher"
+	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
-	cityCache := cache.New()
-	http.HandleFunc("/weather", handlerWeather.New(cityCache).Handle)
-	http.ListenAndServe(":8080", nil)
+	cityCache, err := newCache()
+	if err != nil {
+		log.Fatalf("Could not create the cache: %s", err.Error())
+	}
+	defer cityCache.Close()
+
+	aliases, err := cityName.ParseAliases(os.Getenv("CITY_ALIASES"))
+	if err != nil {
+		log.Fatalf("Could not read the city aliases: %s", err.Error())
+	}
+	names := cityName.New(aliases)
+
+	limits, err := httpClient.ParseRateLimits(os.Getenv("HTTP_RATE_LIMITS"))
+	if err != nil {
+		log.Fatalf("Could not read the rate limits: %s", err.Error())
+	}
+
+	headers, err := httpClient.ParseHeaders(os.Getenv("HTTP_HEADERS"))
+	if err != nil {
+		log.Fatalf("Could not read the request headers: %s", err.Error())
+	}
+
+	var cities []scheduler.City
+	if list := os.Getenv("PREWARM_CITIES"); len(list) > 0 {
+		if cities, err = scheduler.ParseCities(list); err != nil {
+			log.Fatalf("Could not read the pre-warm cities: %s", err.Error())
+		}
+	}
+
+	web, err := newClient(limits, headers)
+	if err != nil {
+		log.Fatalf("Could not create the HTTP client: %s", err.Error())
+	}
+
+	finder, closeFinder, err := newFinder(web)
+	if err != nil {
+		log.Fatalf("Could not create the co-ordinate finder: %s", err.Error())
+	}
+	defer closeFinder()
+
+	places := make(map[string]structs.Place)
+	for _, city := range cities {
+		queries, err := place.ParseList(city.Name, structs.Place{Country: place.DefaultCountry})
+		if err != nil {
+			log.Fatalf("Could not read the pre-warm city %s: %s", city.Name, err.Error())
+		}
+
+		if len(queries) != 1 {
+			log.Fatalf("Could not read the pre-warm city %s: expected one place, found %d", city.Name, len(queries))
+		}
+		places[city.Name] = queries[0].Place
+	}
+
+	positions := make(map[structs.Place]structs.CoOrdinates)
+	for city, pos := range scheduler.Positions(cities) {
+		fixed := places[city]
+		fixed.City = names.Normalize(fixed.City)
+		positions[fixed] = pos
+	}
+	finder = coOrdinateFinder.NewFixed(finder, positions)
+
+	weatherHandler := handlerWeather.New(cityCache, names, finder, weatherFetcher.New(web), handlerWeather.Config{
+		GridEntries: int(envInt("GRID_CACHE_ENTRIES", handlerWeather.DefaultGridEntries)),
+		GridTTL:     envDuration("GRID_CACHE_TTL", handlerWeather.DefaultGridTTL),
+	})
+	if len(cities) > 0 {
+		prewarm, err := newScheduler(cityCache, weatherHandler, cities)
+		if err != nil {
+			log.Fatalf("Could not create the pre-warm schedule: %s", err.Error())
+		}
+		prewarm.Start()
+		defer prewarm.Stop()
+	}
+
+	if token := os.Getenv("ADMIN_TOKEN"); len(token) > 0 {
+		go serveAdmin(handlerAdmin.New(cityCache, weatherHandler, token))
+	}
+
+	http.HandleFunc("/weather", weatherHandler.Handle)
+	http.HandleFunc(handlerHealth.Path, handlerHealth.New(web).Handle)
+	serve(&http.Server{Addr: ":8080"})
+}
+
+// serve runs the server until the process is asked to stop, then lets the
+// requests in progress finish so the caches are closed cleanly
+func serve(server *http.Server) {
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		ctx, cancel := context.WithTimeout(context.Background(), envDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Could not shut down cleanly: %s", err.Error())
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Could not start the server: %s", err.Error())
+	}
+}
+
+// serveAdmin listens on its own address, by default only reachable from the
+// local machine, so the admin routes are never exposed with /weather
+func serveAdmin(admin handlerAdmin.Handler) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(handlerAdmin.PathCache, admin.Handle)
+	mux.HandleFunc(handlerAdmin.PathCache+"/", admin.Handle)
+	mux.HandleFunc(handlerAdmin.PathWarm, admin.Handle)
+
+	if err := http.ListenAndServe(envString("ADMIN_ADDR", "127.0.0.1:8081"), mux); err != nil {
+		log.Fatalf("Could not start the admin server: %s", err.Error())
+	}
+}
+
+func newCache() (cache.Cache, error) {
+	config := cache.Config{
+		TTL:             envDuration("CACHE_TTL", cache.DefaultTTL),
+		StaleWindow:     envDuration("CACHE_STALE_WINDOW", cache.DefaultStaleWindow),
+		JanitorInterval: envDuration("CACHE_JANITOR_INTERVAL", cache.DefaultJanitorInterval),
+	}
+
+	switch os.Getenv("CACHE_BACKEND") {
+	case "file":
+		return cache.NewPersistent(config, envString("CACHE_FILE", "data/forecast-cache.log"))
+	case "redis":
+		client := redisClient.New(redisClient.Config{
+			Addr:     envString("REDIS_ADDR", "127.0.0.1:6379"),
+			Password: os.Getenv("REDIS_PASSWORD"),
+			DB:       int(envInt("REDIS_DB", 0)),
+			Timeout:  envDuration("REDIS_TIMEOUT", redisClient.DefaultTimeout),
+		})
+		return cache.NewRedis(config, client, envString("REDIS_NAMESPACE", cache.DefaultRedisNamespace)), nil
+	}
+
+	policy := cache.Policy(os.Getenv("CACHE_POLICY"))
+	if policy != cache.PolicyLRU && policy != cache.PolicyLFU {
+		return cache.New(config), nil
+	}
+
+	return cache.NewBounded(config, cache.Limits{
+		Policy:     policy,
+		MaxEntries: int(envInt("CACHE_MAX_ENTRIES", 0)),
+		MaxBytes:   envInt("CACHE_MAX_BYTES", 0),
+	}), nil
+}
+
+func newScheduler(cityCache cache.Cache, warmer scheduler.Warmer, cities []scheduler.City) (scheduler.Scheduler, error) {
+	schedule, err := scheduler.NewInterval(envDuration("PREWARM_INTERVAL", scheduler.DefaultInterval))
+	if spec := os.Getenv("PREWARM_SCHEDULE"); len(spec) > 0 {
+		schedule, err = scheduler.NewCron(spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduler.New(cityCache, warmer, scheduler.Config{
+		Cities:      cities,
+		Schedule:    schedule,
+		Jitter:      envDuration("PREWARM_JITTER", scheduler.DefaultJitter),
+		Concurrency: int(envInt("PREWARM_CONCURRENCY", scheduler.DefaultConcurrency)),
+	}), nil
+}
+
+// newClient builds the client for upstream requests. In replay mode every
+// request is answered from the recorded fixtures, so nothing is rate limited
+// or retried, while in record mode every exchange is written to a fixture.
+func newClient(limits map[string]httpClient.RateLimit, headers map[string]http.Header) (httpClient.Breaker, error) {
+	breaker := httpClient.BreakerConfig{
+		Failures: int(envInt("HTTP_BREAKER_FAILURES", httpClient.DefaultBreakerFailures)),
+		CoolDown: envDuration("HTTP_BREAKER_COOL_DOWN", httpClient.DefaultBreakerCoolDown),
+	}
+
+	fixtures := envString("HTTP_FIXTURES", "data/fixtures")
+	mode := envString("HTTP_MODE", httpClient.ModeLive)
+	switch mode {
+	case httpClient.ModeReplay:
+		return httpClient.NewBreaker(httpClient.NewReplaying(fixtures), breaker), nil
+	case httpClient.ModeLive, httpClient.ModeRecord:
+	default:
+		return nil, fmt.Errorf(httpClient.ErrorMode, mode)
+	}
+
+	tlsConfig, err := httpClient.LoadTLS(httpClient.TLSConfig{
+		CAFiles:    envList("HTTP_CA_FILES"),
+		CertFile:   os.Getenv("HTTP_CLIENT_CERT"),
+		KeyFile:    os.Getenv("HTTP_CLIENT_KEY"),
+		MinVersion: envString("HTTP_TLS_MIN_VERSION", httpClient.DefaultTLSVersion),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var proxy *url.URL
+	if raw := os.Getenv("HTTP_PROXY_URL"); len(raw) > 0 {
+		if proxy, err = url.Parse(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	userAgent := map[string]http.Header{
+		httpClient.AnyHost: http.Header{"User-Agent": []string{envString("HTTP_USER_AGENT", httpClient.DefaultUserAgent)}},
+	}
+
+	web := httpClient.New(httpClient.Config{
+		ConnectTimeout: envDuration("HTTP_CONNECT_TIMEOUT", httpClient.DefaultConnectTimeout),
+		ReadTimeout:    envDuration("HTTP_READ_TIMEOUT", httpClient.DefaultReadTimeout),
+		Timeout:        envDuration("HTTP_TIMEOUT", httpClient.DefaultTimeout),
+		ContentTypes:   httpClient.JSONContentTypes,
+		Headers:        httpClient.MergeHeaders(httpClient.DefaultHeaders, userAgent, headers),
+		CacheEntries:   int(envInt("HTTP_CACHE_ENTRIES", httpClient.DefaultCacheEntries)),
+		MaxBodySize:    envInt("HTTP_MAX_BODY_SIZE", httpClient.DefaultMaxBodySize),
+		Proxy:          proxy,
+		NoProxy:        os.Getenv("HTTP_NO_PROXY"),
+		TLS:            tlsConfig,
+	})
+
+	if mode == httpClient.ModeRecord {
+		web = httpClient.NewRecording(web, fixtures)
+	}
+
+	hosts := make(map[string]httpClient.RateLimit)
+	for host, limit := range httpClient.DefaultRateLimits {
+		hosts[host] = limit
+	}
+	for host, limit := range limits {
+		hosts[host] = limit
+	}
+
+	web = httpClient.NewLimited(web, httpClient.LimiterConfig{
+		Hosts:   hosts,
+		MaxWait: envDuration("HTTP_RATE_LIMIT_MAX_WAIT", 0),
+	})
+
+	web = httpClient.NewRetrying(web, httpClient.RetryConfig{
+		Attempts:  int(envInt("HTTP_RETRY_ATTEMPTS", httpClient.DefaultRetryAttempts)),
+		BaseDelay: envDuration("HTTP_RETRY_BASE_DELAY", httpClient.DefaultRetryBaseDelay),
+		MaxDelay:  envDuration("HTTP_RETRY_MAX_DELAY", httpClient.DefaultRetryMaxDelay),
+	})
+
+	return httpClient.NewBreaker(web, breaker), nil
+}
+
+// newFinder builds the co-ordinate finder along with a function which closes
+// the file its cache is kept in, if any
+func newFinder(web httpClient.Client) (coOrdinateFinder.Finder, func(), error) {
+	config := coOrdinateFinder.CacheConfig{
+		TTL:         envDuration("GEOCODE_CACHE_TTL", coOrdinateFinder.DefaultCacheTTL),
+		NegativeTTL: envDuration("GEOCODE_CACHE_NEGATIVE_TTL", coOrdinateFinder.DefaultCacheNegativeTTL),
+	}
+
+	policy := envString("GEOCODE_POLICY", coOrdinateFinder.PolicyImportance)
+	switch policy {
+	case coOrdinateFinder.PolicyImportance, coOrdinateFinder.PolicyCity, coOrdinateFinder.PolicyStrict:
+	default:
+		return nil, nil, fmt.Errorf(coOrdinateFinder.ErrorPolicy, policy)
+	}
+
+	finder, err := newChain(web, coOrdinateFinder.Config{
+		Policy:     policy,
+		Candidates: int(envInt("GEOCODE_CANDIDATES", coOrdinateFinder.DefaultCandidates)),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeFinder := func() {}
+	if path := os.Getenv("GEOCODE_CACHE_FILE"); len(path) > 0 {
+		persistent, err := coOrdinateFinder.NewCachedPersistent(finder, config, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		finder = persistent
+		closeFinder = func() {
+			if err := persistent.Close(); err != nil {
+				log.Printf("Could not close the geocoding cache: %s", err.Error())
+			}
+		}
+	} else {
+		finder = coOrdinateFinder.NewCached(finder, config)
+	}
+
+	if finder, err = coOrdinateFinder.NewCentroids(finder, os.Getenv("ZIP_CENTROIDS_FILE")); err != nil {
+		closeFinder()
+		return nil, nil, err
+	}
+	return finder, closeFinder, nil
+}
+
+// newChain builds the geocoding providers named in GEOCODE_PROVIDERS, each
+// with its own timeout, and chains them as GEOCODE_CHAIN asks
+func newChain(web httpClient.Client, config coOrdinateFinder.Config) (coOrdinateFinder.Finder, error) {
+	timeout := envDuration("GEOCODE_TIMEOUT", 0)
+
+	var providers []coOrdinateFinder.Provider
+	for _, name := range strings.Split(envString("GEOCODE_PROVIDERS", "nominatim,gazetteer"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); len(name) < 1 {
+			continue
+		}
+
+		finder, err := newProvider(name, web, config)
+		if err != nil {
+			return nil, err
+		}
+
+		providers = append(providers, coOrdinateFinder.Provider{
+			Name:    name,
+			Finder:  finder,
+			Timeout: envDuration("GEOCODE_TIMEOUT_"+strings.ToUpper(name), timeout),
+		})
+	}
+
+	mode := envString("GEOCODE_CHAIN", coOrdinateFinder.ChainSequential)
+	switch mode {
+	case coOrdinateFinder.ChainSequential:
+		return coOrdinateFinder.NewChain(providers)
+	case coOrdinateFinder.ChainParallel:
+		return coOrdinateFinder.NewParallel(providers)
+	}
+	return nil, fmt.Errorf(coOrdinateFinder.ErrorChainMode, mode)
+}
+
+func newProvider(name string, web httpClient.Client, config coOrdinateFinder.Config) (coOrdinateFinder.Finder, error) {
+	switch name {
+	case coOrdinateFinder.ProviderNominatim:
+		return coOrdinateFinder.New(web, config), nil
+	case coOrdinateFinder.ProviderCensus:
+		return coOrdinateFinder.NewCensus(web, config), nil
+	case coOrdinateFinder.ProviderPhoton:
+		return coOrdinateFinder.NewPhoton(web, config), nil
+	case coOrdinateFinder.ProviderGazetteer:
+		return coOrdinateFinder.NewGazetteer(coOrdinateFinder.GazetteerConfig{
+			Path:          os.Getenv("GAZETTEER_FILE"),
+			MinPopulation: envInt("GAZETTEER_MIN_POPULATION", 0),
+			Policy:        config.Policy,
+		})
+	}
+	return nil, fmt.Errorf(coOrdinateFinder.ErrorProvider, name)
+}
+
+func envString(name, fallback string) string {
+	if value := os.Getenv(name); len(value) > 0 {
+		return value
+	}
+	return fallback
+}
+
+// envList reads a semicolon delimited list, ignoring empty items
+func envList(name string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ";") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func envInt(name string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
 }
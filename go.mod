@@ -6,4 +6,5 @@ require (
 	github.com/golang/mock v1.6.0
 	github.com/onsi/ginkgo v1.16.5
 	github.com/onsi/gomega v1.19.0
+	golang.org/x/text v0.3.7
 )
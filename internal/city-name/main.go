@@ -0,0 +1,37 @@
+package cityName
+
+import (
+	"fmt"
+	"strings"
+)
+
+// New creates a Normalizer which also replaces each alias, eg. "nyc", with
+// the name it stands for, eg. "new york"
+func New(aliases map[string]string) Normalizer {
+	n := normalizer{
+		aliases: make(map[string]string, len(aliases)),
+	}
+
+	for alias, name := range aliases {
+		n.aliases[Fold(alias)] = Fold(name)
+	}
+	return n
+}
+
+// ParseAliases reads a semicolon delimited list of aliases, eg.
+// "nyc=new york;philly=philadelphia"
+func ParseAliases(list string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, item := range strings.Split(list, ";") {
+		if len(strings.TrimSpace(item)) < 1 {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) < 1 || len(strings.TrimSpace(parts[1])) < 1 {
+			return nil, fmt.Errorf(ErrorAlias, item)
+		}
+		aliases[parts[0]] = parts[1]
+	}
+	return aliases, nil
+}
@@ -0,0 +1,45 @@
+package cityName
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+const (
+	ErrorAlias = "Unrecognised city alias: %s"
+)
+
+type Normalizer interface {
+	Normalize(city string) string
+}
+
+// normalizer reduces the different ways of writing a city to one form: case
+// folded, with accents removed, runs of whitespace collapsed to a single space
+// and any alias replaced by the name it stands for
+type normalizer struct {
+	aliases map[string]string
+}
+
+func (n normalizer) Normalize(city string) string {
+	name := Fold(city)
+	if alias, exists := n.aliases[name]; exists {
+		return alias
+	}
+	return name
+}
+
+// Fold reduces a name to the form used to compare it, without replacing any
+// alias: case folded, with accents removed and whitespace collapsed
+func Fold(city string) string {
+	decomposed := norm.NFKD.String(city)
+
+	var folded strings.Builder
+	for _, char := range decomposed {
+		if unicode.Is(unicode.Mn, char) {
+			continue
+		}
+		folded.WriteRune(unicode.ToLower(char))
+	}
+	return strings.Join(strings.Fields(folded.String()), " ")
+}
@@ -0,0 +1,58 @@
+package cityName
+
+import (
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("City name normalizer", func() {
+	var (
+		normalizer Normalizer
+	)
+
+	BeforeEach(func() {
+		normalizer = New(map[string]string{"NYC": "New  York", "philly": "Philadelphia"})
+	})
+
+	Context("Normalizing a city", func() {
+		DescribeTable("equivalent spellings",
+			func(city, expected string) {
+				Expect(normalizer.Normalize(city)).To(Equal(expected))
+			},
+			Entry("lower case", "chicago", "chicago"),
+			Entry("mixed case", "ChiCAGO", "chicago"),
+			Entry("surrounding whitespace", " chicago\t", "chicago"),
+			Entry("repeated inner whitespace", "new   york", "new york"),
+			Entry("accents", "San José", "san jose"),
+			Entry("decomposed accents", "San Jose\u0301", "san jose"),
+			Entry("compatibility characters", "ＣＨＩＣＡＧＯ", "chicago"),
+			Entry("an alias", " nyc ", "new york"),
+			Entry("another alias", "Philly", "philadelphia"),
+		)
+	})
+
+	Context("Parsing aliases", func() {
+		When("a list of aliases is given", func() {
+			It("should return each alias", func() {
+				aliases, err := ParseAliases("nyc=new york;philly=philadelphia;")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(aliases).To(Equal(map[string]string{"nyc": "new york", "philly": "philadelphia"}))
+			})
+		})
+
+		When("an alias has no name", func() {
+			It("should return an error", func() {
+				_, err := ParseAliases("nyc=new york;philly")
+				Expect(err).To(Equal(fmt.Errorf(ErrorAlias, "philly")))
+			})
+		})
+	})
+})
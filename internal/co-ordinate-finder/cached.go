@@ -0,0 +1,168 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	fileStore "github.com/jddcode/tech-test-ennismore/internal/file-store"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheTTL         = time.Hour * 24 * 30
+	DefaultCacheNegativeTTL = time.Hour
+
+	minimumSweepSize = 64
+)
+
+type CacheConfig struct {
+	TTL, NegativeTTL time.Duration
+}
+
+// PersistentFinder is a Finder whose cache is kept in a file, which is closed
+// by Close once the Finder is no longer needed
+type PersistentFinder interface {
+	Finder
+	Close() error
+}
+
+type cachedPosition struct {
+	Position   structs.CoOrdinates `json:"position"`
+	NotFound   bool                `json:"notFound,omitempty"`
+	Candidates []structs.Candidate `json:"candidates,omitempty"`
+	expires    time.Time
+}
+
+// cachedFinder wraps another Finder and remembers its answers, including
+// cities it could not find and the candidates for cities it found too many
+// of, which are kept for the shorter negative TTL so a typo or an ambiguous
+// name does not hit the upstream service on every request. Other errors are
+// not cached. Expired entries are swept whenever the cache has doubled in
+// size since the last sweep. A failure to write the cache's file is logged,
+// as the answers are still remembered in memory.
+type cachedFinder struct {
+	finder    Finder
+	config    CacheConfig
+	positions map[string]cachedPosition
+	store     fileStore.Store
+	sweepAt   int
+	lock      sync.Mutex
+}
+
+func (c *cachedFinder) Find(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+	key := place.Key()
+
+	c.lock.Lock()
+	cached, exists := c.positions[key]
+	c.lock.Unlock()
+
+	if exists && time.Now().Before(cached.expires) {
+		if cached.NotFound {
+			return structs.CoOrdinates{}, ErrNoData
+		}
+
+		if len(cached.Candidates) > 0 {
+			return structs.CoOrdinates{}, &AmbiguousError{Place: place, Candidates: cached.Candidates}
+		}
+		return cached.Position, nil
+	}
+
+	pos, err := c.finder.Find(ctx, place)
+	ambiguous := &AmbiguousError{}
+	switch {
+	case err == nil:
+		c.remember(key, cachedPosition{Position: pos}, c.config.TTL)
+	case errors.As(err, &ambiguous) && len(ambiguous.Candidates) > 0:
+		c.remember(key, cachedPosition{Candidates: ambiguous.Candidates}, c.config.NegativeTTL)
+	case notFound(err):
+		c.remember(key, cachedPosition{NotFound: true}, c.config.NegativeTTL)
+	}
+	return pos, err
+}
+
+func (c *cachedFinder) Close() error {
+	if c.store == nil {
+		return nil
+	}
+	return c.store.Close()
+}
+
+func (c *cachedFinder) remember(key string, position cachedPosition, ttl time.Duration) {
+	position.expires = time.Now().Add(ttl)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.positions[key] = position
+	if c.store != nil {
+		if value, err := json.Marshal(position); err == nil {
+			err = c.store.Append(fileStore.Record{
+				Key:     key,
+				Value:   value,
+				Expires: position.expires,
+			})
+			if err != nil {
+				log.Printf("Could not persist the co-ordinates for %s: %s", key, err.Error())
+			}
+		}
+	}
+
+	if len(c.positions) >= c.sweepAt {
+		c.sweep()
+	}
+}
+
+func (c *cachedFinder) sweep() {
+	now := time.Now()
+	for key, position := range c.positions {
+		if !now.Before(position.expires) {
+			delete(c.positions, key)
+		}
+	}
+	c.sweepAt = len(c.positions)*2 + minimumSweepSize
+
+	if c.store == nil || c.store.Appended() < c.sweepAt {
+		return
+	}
+
+	records := make(map[string]fileStore.Record)
+	for key, position := range c.positions {
+		if value, err := json.Marshal(position); err == nil {
+			records[key] = fileStore.Record{
+				Key:     key,
+				Value:   value,
+				Expires: position.expires,
+			}
+		}
+	}
+	if err := c.store.Compact(records); err != nil {
+		log.Printf("Could not compact the geocoding cache: %s", err.Error())
+	}
+}
+
+func (c *cachedFinder) load() error {
+	records, err := c.store.Load()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for key, record := range records {
+		if !now.Before(record.Expires) {
+			continue
+		}
+
+		position := cachedPosition{}
+		if err := json.Unmarshal(record.Value, &position); err != nil {
+			continue
+		}
+
+		position.expires = record.Expires
+		c.positions[key] = position
+	}
+	c.sweepAt = len(c.positions)*2 + minimumSweepSize
+	return nil
+}
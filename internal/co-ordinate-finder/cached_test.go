@@ -0,0 +1,195 @@
+package coOrdinateFinder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	fileStore "github.com/jddcode/tech-test-ennismore/internal/file-store"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Cached co-ordinate finder", func() {
+	var (
+		mockController *gomock.Controller
+		mockFinder     *mocks.MockFinder
+		cached         Finder
+		chicago        structs.CoOrdinates
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockFinder = mocks.NewMockFinder(mockController)
+		cached = NewCached(mockFinder, CacheConfig{})
+		chicago = structs.CoOrdinates{Latitude: 41.87, Longitude: -87.62}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	Context("Finding the co-ordinates for a city", func() {
+		When("the same city is requested twice", func() {
+			It("should only ask the wrapped finder once", func() {
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "chicago", Country: "us"}).Return(chicago, nil).Times(1)
+
+				pos, err := cached.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+
+				pos, err = cached.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+			})
+		})
+
+		When("the city cannot be found", func() {
+			It("should remember that it was not found", func() {
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "nowhere", Country: "us"}).Return(structs.CoOrdinates{}, ErrNoData).Times(1)
+
+				_, err := cached.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+				Expect(err).To(Equal(ErrNoData))
+
+				_, err = cached.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+				Expect(err).To(Equal(ErrNoData))
+			})
+		})
+
+		When("the wrapped finder says the city cannot be found by way of another error", func() {
+			It("should still remember that it was not found", func() {
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "nowhere", Country: "us"}).Return(structs.CoOrdinates{}, fmt.Errorf("gazetteer: %w", ErrNoData)).Times(1)
+
+				cached.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+				_, err := cached.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+				Expect(errors.Is(err, ErrNoData)).To(BeTrue())
+			})
+		})
+
+		When("the negative TTL has passed for a city which could not be found", func() {
+			It("should ask the wrapped finder again", func() {
+				cached = NewCached(mockFinder, CacheConfig{NegativeTTL: time.Nanosecond})
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "nowhere", Country: "us"}).Return(structs.CoOrdinates{}, ErrNoData).Times(2)
+
+				cached.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+				time.Sleep(time.Millisecond)
+				cached.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+			})
+		})
+
+		When("the city could be any of several places", func() {
+			It("should remember the candidates", func() {
+				ambiguous := &AmbiguousError{
+					Place:      structs.Place{City: "springfield", Country: "us"},
+					Candidates: []structs.Candidate{{Name: "Springfield, Illinois"}, {Name: "Springfield, Missouri"}},
+				}
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "springfield", Country: "us"}).Return(structs.CoOrdinates{}, ambiguous).Times(1)
+
+				_, err := cached.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+				Expect(err).To(Equal(ambiguous))
+
+				_, err = cached.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+				Expect(err).To(Equal(ambiguous))
+			})
+		})
+
+		When("the negative TTL has passed for a city which could be any of several places", func() {
+			It("should ask the wrapped finder again", func() {
+				cached = NewCached(mockFinder, CacheConfig{NegativeTTL: time.Nanosecond})
+				ambiguous := &AmbiguousError{Candidates: []structs.Candidate{{Name: "Springfield, Illinois"}, {Name: "Springfield, Missouri"}}}
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "springfield", Country: "us"}).Return(structs.CoOrdinates{}, ambiguous).Times(2)
+
+				cached.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+				time.Sleep(time.Millisecond)
+				cached.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+			})
+		})
+
+		When("the wrapped finder has some other error", func() {
+			It("should not remember the error", func() {
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "chicago", Country: "us"}).Return(structs.CoOrdinates{}, errors.New("some http error"))
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "chicago", Country: "us"}).Return(chicago, nil)
+
+				_, err := cached.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).To(HaveOccurred())
+
+				pos, err := cached.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+			})
+		})
+	})
+
+	Context("Persisting the cache to a file", func() {
+		When("the cache is recreated from the same file", func() {
+			It("should remember cities found before", func() {
+				dir, err := ioutil.TempDir("", "cached-finder")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(dir)
+				path := filepath.Join(dir, "geocode.log")
+
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "chicago", Country: "us"}).Return(chicago, nil).Times(1)
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "nowhere", Country: "us"}).Return(structs.CoOrdinates{}, ErrNoData).Times(1)
+				ambiguous := &AmbiguousError{
+					Place:      structs.Place{City: "springfield", Country: "us"},
+					Candidates: []structs.Candidate{{Name: "Springfield, Illinois", Position: chicago}, {Name: "Springfield, Missouri"}},
+				}
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "springfield", Country: "us"}).Return(structs.CoOrdinates{}, ambiguous).Times(1)
+
+				first, err := NewCachedPersistent(mockFinder, CacheConfig{}, path)
+				Expect(err).ToNot(HaveOccurred())
+				first.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				first.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+				first.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+				Expect(first.Close()).To(Succeed())
+
+				second, err := NewCachedPersistent(mockFinder, CacheConfig{}, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer second.Close()
+
+				pos, err := second.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+
+				_, err = second.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+				Expect(err).To(Equal(ErrNoData))
+
+				_, err = second.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+				Expect(err).To(Equal(ambiguous))
+			})
+		})
+
+		When("the cache's file cannot be written", func() {
+			It("should log the failure and still remember the city", func() {
+				dir, err := ioutil.TempDir("", "cached-finder")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(dir)
+
+				logged := &bytes.Buffer{}
+				log.SetOutput(logged)
+				defer log.SetOutput(os.Stderr)
+
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "chicago", Country: "us"}).Return(chicago, nil).Times(1)
+
+				cached, err := NewCachedPersistent(mockFinder, CacheConfig{}, filepath.Join(dir, "geocode.log"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cached.Close()).To(Succeed())
+
+				for i := 0; i < 2; i++ {
+					pos, err := cached.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(pos).To(Equal(chicago))
+				}
+				Expect(logged.String()).To(ContainSubstring("Could not persist the co-ordinates for chicago,us: " + fileStore.ErrorClosed))
+			})
+		})
+	})
+})
@@ -0,0 +1,125 @@
+package coOrdinateFinder
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrorAmbiguous = "%s could be any of %d places"
+)
+
+// AmbiguousError is returned when the strict policy finds more than one
+// distinct place for a query, giving each of them ranked by importance
+type AmbiguousError struct {
+	Place      structs.Place
+	Candidates []structs.Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf(ErrorAmbiguous, e.Place, len(e.Candidates))
+}
+
+// IsAmbiguous reports whether err was caused by a query matching more than
+// one place
+func IsAmbiguous(err error) bool {
+	ambiguous := &AmbiguousError{}
+	return errors.As(err, &ambiguous)
+}
+
+// candidate reads the result as a candidate for the place. Its Place narrows
+// the query down to the state and country Nominatim found it in.
+func (r resultItem) candidate(place structs.Place) (structs.Candidate, error) {
+	lat, err := strconv.ParseFloat(r.Lat, 64)
+	if err != nil {
+		return structs.Candidate{}, fmt.Errorf(ErrorBadLatitude, r.Lat)
+	}
+
+	lon, err := strconv.ParseFloat(r.Lon, 64)
+	if err != nil {
+		return structs.Candidate{}, fmt.Errorf(ErrorBadLongitude, r.Lon)
+	}
+
+	bounds := structs.BoundingBox{South: lat, North: lat, West: lon, East: lon}
+	if len(r.Boundingbox) > 0 {
+		if bounds, err = boundingBox(r.Boundingbox); err != nil {
+			return structs.Candidate{}, err
+		}
+	}
+
+	if len(r.Address.CountryCode) > 0 {
+		place.Country = strings.ToLower(r.Address.CountryCode)
+	}
+
+	if len(r.Address.State) > 0 {
+		place.State = strings.ToLower(r.Address.State)
+	}
+
+	return structs.Candidate{
+		Name:        r.DisplayName,
+		Class:       r.Class,
+		Type:        r.Type,
+		Importance:  r.Importance,
+		BoundingBox: bounds,
+		Position:    structs.CoOrdinates{Latitude: lat, Longitude: lon},
+		Place:       place,
+	}, nil
+}
+
+// boundingBox reads a Nominatim bounding box, given as the south and north
+// latitudes then the west and east longitudes
+func boundingBox(edges []string) (structs.BoundingBox, error) {
+	if len(edges) != 4 {
+		return structs.BoundingBox{}, fmt.Errorf(ErrorBadBounds, edges)
+	}
+
+	values := make([]float64, 4)
+	for index, edge := range edges {
+		value, err := strconv.ParseFloat(edge, 64)
+		if err != nil {
+			return structs.BoundingBox{}, fmt.Errorf(ErrorBadBounds, edges)
+		}
+		values[index] = value
+	}
+
+	return structs.BoundingBox{
+		South: values[0],
+		North: values[1],
+		West:  values[2],
+		East:  values[3],
+	}, nil
+}
+
+// rank orders the candidates by importance, keeping Nominatim's own order for
+// those of equal importance
+func rank(candidates []structs.Candidate) []structs.Candidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Importance > candidates[j].Importance
+	})
+	return candidates
+}
+
+// distinct drops the candidates which are the same place as a more important
+// one, such as the boundary of a city and the point which marks its centre,
+// taking a candidate within another's bounding box to be the same place
+func distinct(candidates []structs.Candidate) []structs.Candidate {
+	kept := make([]structs.Candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		same := false
+		for _, other := range kept {
+			if other.BoundingBox.Contains(candidate.Position) || candidate.BoundingBox.Contains(other.Position) {
+				same = true
+				break
+			}
+		}
+
+		if !same {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
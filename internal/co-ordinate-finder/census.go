@@ -0,0 +1,105 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"github.com/jddcode/tech-test-ennismore/internal/place"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"net/url"
+	"strings"
+)
+
+const (
+	ErrorCensusCountry = "The Census geocoder only finds places in the US"
+)
+
+var (
+	// ErrCensusCountry is returned for a place outside the US, which the
+	// Census geocoder cannot find
+	ErrCensusCountry = errors.New(ErrorCensusCountry)
+)
+
+// censusFinder asks the US Census Bureau geocoder, which only knows places in
+// the US and is written for street addresses, so finds a city or ZIP code on
+// its own far less often than Nominatim
+type censusFinder struct {
+	web    httpClient.Client
+	config Config
+}
+
+func (f censusFinder) Find(ctx context.Context, query structs.Place) (structs.CoOrdinates, error) {
+	if len(query.City) < 1 && len(query.Postcode) < 1 {
+		return structs.CoOrdinates{}, errors.New(ErrorNoCity)
+	}
+
+	if query.Country != place.DefaultCountry {
+		return structs.CoOrdinates{}, ErrCensusCountry
+	}
+
+	address := query.Postcode
+	if len(address) < 1 {
+		address = strings.Join(nonEmpty(query.City, query.State), ", ")
+	}
+
+	values := url.Values{}
+	values.Set("address", address)
+	values.Set("benchmark", "Public_AR_Current")
+	values.Set("format", "json")
+
+	body, err := f.web.Open(ctx, "https://geocoding.geo.census.gov/geocoder/locations/onelineaddress?"+values.Encode())
+	if err != nil {
+		return structs.CoOrdinates{}, fmt.Errorf(ErrorHTTPGet, err)
+	}
+	defer body.Close()
+
+	response := censusResponse{}
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return structs.CoOrdinates{}, decodeError(err)
+	}
+
+	matches := response.Result.AddressMatches
+	if len(matches) < 1 {
+		return structs.CoOrdinates{}, ErrNoData
+	}
+
+	if len(matches) > f.config.Candidates {
+		matches = matches[:f.config.Candidates]
+	}
+
+	candidates := make([]structs.Candidate, 0, len(matches))
+	for _, match := range matches {
+		candidates = append(candidates, match.candidate(query))
+	}
+	return choose(f.config.Policy, query, candidates)
+}
+
+// candidate reads the match as a candidate for the place. The Census
+// geocoder gives a point for each match, x being its longitude, and the state
+// as its USPS code.
+func (m censusMatch) candidate(query structs.Place) structs.Candidate {
+	pos := structs.CoOrdinates{Latitude: m.Coordinates.Y, Longitude: m.Coordinates.X}
+	if state, err := place.State(m.AddressComponents.State, place.DefaultCountry); err == nil && len(state) > 0 {
+		query.State = state
+	}
+
+	return structs.Candidate{
+		Name:        m.MatchedAddress,
+		Class:       "address",
+		BoundingBox: structs.BoundingBox{South: pos.Latitude, North: pos.Latitude, West: pos.Longitude, East: pos.Longitude},
+		Position:    pos,
+		Place:       query,
+	}
+}
+
+func nonEmpty(values ...string) []string {
+	kept := make([]string, 0, len(values))
+	for _, value := range values {
+		if len(value) > 0 {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
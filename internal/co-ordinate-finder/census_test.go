@@ -0,0 +1,91 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Census geocoder finder", func() {
+	var (
+		mockController *gomock.Controller
+		mockHttpClient *mocks.MockClient
+		census         Finder
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockHttpClient = mocks.NewMockClient(mockController)
+		census = NewCensus(mockHttpClient, Config{})
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	When("the place has a city and state", func() {
+		It("should ask for them as one line and return the match's co-ordinates", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), "https://geocoding.geo.census.gov/geocoder/locations/onelineaddress?address=springfield%2C+illinois&benchmark=Public_AR_Current&format=json").
+				Return(stream(`{"result":{"addressMatches":[{"matchedAddress":"SPRINGFIELD, IL","coordinates":{"x":-89.64,"y":39.8},"addressComponents":{"state":"IL"}}]}}`), nil)
+
+			pos, err := census.Find(context.Background(), structs.Place{City: "springfield", State: "illinois", Country: "us"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 39.8, Longitude: -89.64}))
+		})
+	})
+
+	When("the place is a ZIP code", func() {
+		It("should ask for the ZIP code", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), "https://geocoding.geo.census.gov/geocoder/locations/onelineaddress?address=60601&benchmark=Public_AR_Current&format=json").
+				Return(stream(`{"result":{"addressMatches":[{"coordinates":{"x":-87.62,"y":41.88}}]}}`), nil)
+
+			pos, err := census.Find(context.Background(), structs.Place{Postcode: "60601", Country: "us"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 41.88, Longitude: -87.62}))
+		})
+	})
+
+	When("there is no match", func() {
+		It("should return an error", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"result":{"addressMatches":[]}}`), nil)
+
+			_, err := census.Find(context.Background(), structs.Place{City: "nowhere", Country: "us"})
+			Expect(err).To(Equal(ErrNoData))
+		})
+	})
+
+	When("the policy is strict and there are several matches", func() {
+		It("should return the candidates", func() {
+			census = NewCensus(mockHttpClient, Config{Policy: PolicyStrict})
+			mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).
+				Return(stream(`{"result":{"addressMatches":[{"coordinates":{"x":-89.64,"y":39.8},"addressComponents":{"state":"IL"}},{"coordinates":{"x":-93.29,"y":37.2},"addressComponents":{"state":"MO"}}]}}`), nil)
+
+			_, err := census.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+			ambiguous := &AmbiguousError{}
+			Expect(errors.As(err, &ambiguous)).To(BeTrue())
+			Expect(ambiguous.Candidates).To(HaveLen(2))
+			Expect(ambiguous.Candidates[1].Place).To(Equal(structs.Place{City: "springfield", State: "missouri", Country: "us"}))
+		})
+	})
+
+	When("the place is outside the US", func() {
+		It("should return an error without asking", func() {
+			_, err := census.Find(context.Background(), structs.Place{City: "london", Country: "gb"})
+			Expect(err).To(Equal(ErrCensusCountry))
+		})
+	})
+
+	When("there is an error calling the web service", func() {
+		It("should return an error", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(nil, errors.New("error carrying out GET request"))
+
+			_, err := census.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+			Expect(err).To(Equal(fmt.Errorf(ErrorHTTPGet, errors.New("error carrying out GET request"))))
+		})
+	})
+})
@@ -0,0 +1,116 @@
+package coOrdinateFinder
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/place"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrorCentroids      = "Could not read the ZIP code centroids: %s"
+	ErrorCentroidHeader = "Could not read the ZIP code centroids: expected GEOID, INTPTLAT and INTPTLONG columns"
+	ErrorCentroidLine   = "Could not read the ZIP code centroids on line %d"
+)
+
+var (
+	// embeddedZips is the centroids of the downtown ZIP codes of the larger
+	// US cities, in the comma separated layout, with approximate positions
+	//go:embed data/zips.csv
+	embeddedZips []byte
+
+	centroidColumns = map[string][]string{
+		"zip": {"geoid", "zip", "zcta5"},
+		"lat": {"intptlat", "lat", "latitude"},
+		"lon": {"intptlong", "lon", "longitude"},
+	}
+)
+
+// centroidFinder answers US postcodes from a table of the centroid of each
+// ZIP code, and passes any other place, or a ZIP code missing from the table,
+// to the wrapped Finder
+type centroidFinder struct {
+	finder    Finder
+	positions map[string]structs.CoOrdinates
+}
+
+func (c centroidFinder) Find(ctx context.Context, query structs.Place) (structs.CoOrdinates, error) {
+	if len(query.Postcode) > 0 && query.Country == place.DefaultCountry {
+		if pos, exists := c.positions[query.Postcode]; exists {
+			return pos, nil
+		}
+	}
+	return c.finder.Find(ctx, query)
+}
+
+// readCentroids reads a tab or comma separated table of ZIP code centroids
+// with a header row, such as the Census Bureau's ZCTA gazetteer file, which
+// has the columns GEOID, INTPTLAT and INTPTLONG amongst others
+func readCentroids(reader io.Reader) (map[string]structs.CoOrdinates, error) {
+	scanner := bufio.NewScanner(reader)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf(ErrorCentroids, err.Error())
+		}
+		return nil, errors.New(ErrorCentroidHeader)
+	}
+
+	separator := ","
+	if strings.Contains(scanner.Text(), "\t") {
+		separator = "\t"
+	}
+
+	columns := make(map[string]int)
+	for index, name := range strings.Split(scanner.Text(), separator) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for column, names := range centroidColumns {
+			for _, known := range names {
+				if name == known {
+					columns[column] = index
+				}
+			}
+		}
+	}
+
+	if len(columns) != len(centroidColumns) {
+		return nil, errors.New(ErrorCentroidHeader)
+	}
+
+	positions := make(map[string]structs.CoOrdinates)
+	for line := 2; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) < 1 {
+			continue
+		}
+
+		fields := strings.Split(scanner.Text(), separator)
+		if len(fields) <= columns["zip"] || len(fields) <= columns["lat"] || len(fields) <= columns["lon"] {
+			return nil, fmt.Errorf(ErrorCentroidLine, line)
+		}
+
+		lat, err := strconv.ParseFloat(strings.TrimSpace(fields[columns["lat"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf(ErrorCentroidLine, line)
+		}
+
+		lon, err := strconv.ParseFloat(strings.TrimSpace(fields[columns["lon"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf(ErrorCentroidLine, line)
+		}
+
+		positions[strings.TrimSpace(fields[columns["zip"]])] = structs.CoOrdinates{
+			Latitude:  lat,
+			Longitude: lon,
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(ErrorCentroids, err.Error())
+	}
+	return positions, nil
+}
@@ -0,0 +1,136 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe("ZIP code centroid finder", func() {
+	var (
+		mockController *gomock.Controller
+		mockFinder     *mocks.MockFinder
+		centroids      Finder
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockFinder = mocks.NewMockFinder(mockController)
+		centroids = centroidFinder{
+			finder:    mockFinder,
+			positions: map[string]structs.CoOrdinates{"60601": {Latitude: 41.88, Longitude: -87.62}},
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	Context("Finding the co-ordinates for a place", func() {
+		When("the place is a ZIP code in the table", func() {
+			It("should return its centroid without asking the wrapped finder", func() {
+				pos, err := centroids.Find(context.Background(), structs.Place{Postcode: "60601", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 41.88, Longitude: -87.62}))
+			})
+		})
+
+		When("the place is a ZIP code missing from the table", func() {
+			It("should ask the wrapped finder", func() {
+				place := structs.Place{Postcode: "10001", Country: "us"}
+				mockFinder.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{Latitude: 40.75, Longitude: -73.99}, nil)
+
+				pos, err := centroids.Find(context.Background(), place)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 40.75, Longitude: -73.99}))
+			})
+		})
+
+		When("the place is a postcode in another country", func() {
+			It("should ask the wrapped finder", func() {
+				place := structs.Place{Postcode: "60601", Country: "de"}
+				mockFinder.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, ErrNoData)
+
+				_, err := centroids.Find(context.Background(), place)
+				Expect(err).To(Equal(ErrNoData))
+			})
+		})
+	})
+
+	Context("Reading a table of centroids", func() {
+		When("the table is a Census gazetteer file", func() {
+			It("should read the centroid of each ZIP code", func() {
+				positions, err := readCentroids(strings.NewReader(
+					"GEOID\tALAND\tAWATER\tALAND_SQMI\tAWATER_SQMI\tINTPTLAT\tINTPTLONG                \n" +
+						"60601\t1066658\t71547\t0.412\t0.028\t41.886262\t-87.618425\n" +
+						"10001\t1618137\t0\t0.625\t0.000\t40.750633\t-73.997177\n"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(positions).To(Equal(map[string]structs.CoOrdinates{
+					"60601": {Latitude: 41.886262, Longitude: -87.618425},
+					"10001": {Latitude: 40.750633, Longitude: -73.997177},
+				}))
+			})
+		})
+
+		When("the table is comma separated", func() {
+			It("should read the centroid of each ZIP code", func() {
+				positions, err := readCentroids(strings.NewReader("zip,lat,lon\n60601,41.88,-87.62\n\n"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(positions).To(Equal(map[string]structs.CoOrdinates{"60601": {Latitude: 41.88, Longitude: -87.62}}))
+			})
+		})
+
+		When("the table has no centroid columns", func() {
+			It("should return an error", func() {
+				_, err := readCentroids(strings.NewReader("zip,name\n60601,chicago\n"))
+				Expect(err).To(Equal(errors.New(ErrorCentroidHeader)))
+			})
+		})
+
+		When("a line has a latitude which is not a number", func() {
+			It("should return an error naming the line", func() {
+				_, err := readCentroids(strings.NewReader("zip,lat,lon\n60601,41.88,-87.62\n10001,north,-73.99\n"))
+				Expect(err).To(Equal(fmt.Errorf(ErrorCentroidLine, 3)))
+			})
+		})
+
+		When("no file is given", func() {
+			It("should use the embedded ZIP codes", func() {
+				finder, err := NewCentroids(mockFinder, "")
+				Expect(err).ToNot(HaveOccurred())
+
+				pos, err := finder.Find(context.Background(), structs.Place{Postcode: "60601", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos.Latitude).To(BeNumerically("~", 41.88, 0.01))
+				Expect(pos.Longitude).To(BeNumerically("~", -87.62, 0.01))
+			})
+		})
+
+		When("the table is loaded from a file", func() {
+			It("should wrap the finder with the table", func() {
+				dir, err := ioutil.TempDir("", "centroids")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(dir)
+
+				path := filepath.Join(dir, "zips.csv")
+				Expect(ioutil.WriteFile(path, []byte("zip,lat,lon\n60601,41.88,-87.62\n"), 0600)).To(Succeed())
+
+				finder, err := NewCentroids(mockFinder, path)
+				Expect(err).ToNot(HaveOccurred())
+
+				pos, err := finder.Find(context.Background(), structs.Place{Postcode: "60601", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 41.88, Longitude: -87.62}))
+			})
+		})
+	})
+})
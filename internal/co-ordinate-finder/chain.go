@@ -0,0 +1,168 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"strings"
+	"time"
+)
+
+const (
+	ErrorChain       = "No geocoding provider could find %s: %s"
+	ErrorChainMode   = "Unknown geocoding chain mode %q, expected sequential or parallel"
+	ErrorProvider    = "Unknown geocoding provider %q, expected nominatim, census, photon or gazetteer"
+	ErrorNoProviders = "At least one geocoding provider is needed"
+
+	// ChainSequential asks each provider in turn until one finds the place and
+	// ChainParallel asks them all at once, taking the first to find it
+	ChainSequential = "sequential"
+	ChainParallel   = "parallel"
+
+	ProviderNominatim = "nominatim"
+	ProviderCensus    = "census"
+	ProviderPhoton    = "photon"
+	ProviderGazetteer = "gazetteer"
+)
+
+// Provider is one of the Finders in a chain, named so its failures can be
+// told apart. A Timeout of zero leaves the provider bounded only by the
+// request's own context.
+type Provider struct {
+	Name    string
+	Finder  Finder
+	Timeout time.Duration
+}
+
+// ProviderError is why one provider in a chain did not find a place
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e ProviderError) Error() string {
+	return e.Provider + ": " + e.Err.Error()
+}
+
+func (e ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ChainError is returned when no provider in a chain found the place, giving
+// the error of each in the order they were configured. It unwraps to the
+// first provider's error, so whether the place could not be found or a third
+// party failed is judged by the preferred provider.
+type ChainError struct {
+	Place  structs.Place
+	Errors []ProviderError
+}
+
+func (e *ChainError) Error() string {
+	reasons := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		reasons = append(reasons, err.Error())
+	}
+	return fmt.Sprintf(ErrorChain, e.Place, strings.Join(reasons, "; "))
+}
+
+func (e *ChainError) Unwrap() error {
+	if len(e.Errors) < 1 {
+		return nil
+	}
+	return e.Errors[0]
+}
+
+// notFound reports whether err says the place does not exist, rather than
+// that it could not be looked for. A chain has only not found the place if
+// none of its providers found it.
+func notFound(err error) bool {
+	chain := &ChainError{}
+	if errors.As(err, &chain) {
+		for _, providerErr := range chain.Errors {
+			if !notFound(providerErr.Err) {
+				return false
+			}
+		}
+		return len(chain.Errors) > 0
+	}
+	return errors.Is(err, ErrNoData) || errors.Is(err, ErrCensusCountry)
+}
+
+// chainFinder asks several providers for a place. An ambiguous place is an
+// answer rather than a failure, so stops a sequential chain, while a parallel
+// chain only returns it if no provider finds the place outright.
+type chainFinder struct {
+	providers []Provider
+	parallel  bool
+}
+
+type providerResult struct {
+	index int
+	pos   structs.CoOrdinates
+	err   error
+}
+
+func (c chainFinder) Find(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+	if c.parallel {
+		return c.findParallel(ctx, place)
+	}
+
+	errs := make([]ProviderError, 0, len(c.providers))
+	for _, provider := range c.providers {
+		pos, err := c.ask(ctx, provider, place)
+		if err == nil || IsAmbiguous(err) {
+			return pos, err
+		}
+
+		if ctx.Err() != nil {
+			return structs.CoOrdinates{}, ctx.Err()
+		}
+		errs = append(errs, ProviderError{Provider: provider.Name, Err: err})
+	}
+	return structs.CoOrdinates{}, &ChainError{Place: place, Errors: errs}
+}
+
+// findParallel asks every provider at once and cancels the others as soon as
+// one finds the place
+func (c chainFinder) findParallel(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+	askCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan providerResult, len(c.providers))
+	for index, provider := range c.providers {
+		go func(index int, provider Provider) {
+			pos, err := c.ask(askCtx, provider, place)
+			results <- providerResult{index: index, pos: pos, err: err}
+		}(index, provider)
+	}
+
+	errs := make([]ProviderError, len(c.providers))
+	for range c.providers {
+		result := <-results
+		if result.err == nil {
+			return result.pos, nil
+		}
+		errs[result.index] = ProviderError{Provider: c.providers[result.index].Name, Err: result.err}
+	}
+
+	if ctx.Err() != nil {
+		return structs.CoOrdinates{}, ctx.Err()
+	}
+
+	for _, err := range errs {
+		if IsAmbiguous(err.Err) {
+			return structs.CoOrdinates{}, err.Err
+		}
+	}
+	return structs.CoOrdinates{}, &ChainError{Place: place, Errors: errs}
+}
+
+func (c chainFinder) ask(ctx context.Context, provider Provider, place structs.Place) (structs.CoOrdinates, error) {
+	if provider.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, provider.Timeout)
+		defer cancel()
+	}
+	return provider.Finder.Find(ctx, place)
+}
@@ -0,0 +1,228 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"time"
+)
+
+var _ = Describe("Chained finder", func() {
+	var (
+		mockController *gomock.Controller
+		mockFirst      *mocks.MockFinder
+		mockSecond     *mocks.MockFinder
+		providers      []Provider
+		place          structs.Place
+		chicago        structs.CoOrdinates
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockFirst = mocks.NewMockFinder(mockController)
+		mockSecond = mocks.NewMockFinder(mockController)
+		providers = []Provider{
+			{Name: "first", Finder: mockFirst},
+			{Name: "second", Finder: mockSecond},
+		}
+		place = structs.Place{City: "chicago", Country: "us"}
+		chicago = structs.CoOrdinates{Latitude: 41.85, Longitude: -87.65}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	When("there are no providers", func() {
+		It("should return an error", func() {
+			_, err := NewChain(nil)
+			Expect(err).To(Equal(errors.New(ErrorNoProviders)))
+
+			_, err = NewParallel(nil)
+			Expect(err).To(Equal(errors.New(ErrorNoProviders)))
+		})
+	})
+
+	Context("Asking the providers in turn", func() {
+		var (
+			chain Finder
+		)
+
+		BeforeEach(func() {
+			var err error
+			chain, err = NewChain(providers)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		When("the first provider finds the place", func() {
+			It("should not ask the others", func() {
+				mockFirst.EXPECT().Find(gomock.Any(), place).Return(chicago, nil)
+
+				pos, err := chain.Find(context.Background(), place)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+			})
+		})
+
+		When("the first provider fails", func() {
+			It("should return what the next finds", func() {
+				mockFirst.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, errors.New("unavailable"))
+				mockSecond.EXPECT().Find(gomock.Any(), place).Return(chicago, nil)
+
+				pos, err := chain.Find(context.Background(), place)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+			})
+		})
+
+		When("every provider fails", func() {
+			It("should return why each failed", func() {
+				mockFirst.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, errors.New("unavailable"))
+				mockSecond.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, ErrNoData)
+
+				_, err := chain.Find(context.Background(), place)
+				Expect(err).To(Equal(&ChainError{Place: place, Errors: []ProviderError{
+					{Provider: "first", Err: errors.New("unavailable")},
+					{Provider: "second", Err: ErrNoData},
+				}}))
+				Expect(err.Error()).To(Equal("No geocoding provider could find chicago, US: first: unavailable; second: " + ErrorNoData))
+			})
+		})
+
+		When("the first provider finds the place ambiguous", func() {
+			It("should not ask the others", func() {
+				ambiguous := &AmbiguousError{Place: place}
+				mockFirst.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, ambiguous)
+
+				_, err := chain.Find(context.Background(), place)
+				Expect(err).To(Equal(ambiguous))
+			})
+		})
+
+		When("the request is cancelled", func() {
+			It("should not ask the others", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				mockFirst.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, context.Canceled)
+
+				_, err := chain.Find(ctx, place)
+				Expect(err).To(Equal(context.Canceled))
+			})
+		})
+
+		When("a provider has a timeout", func() {
+			It("should give up on it and ask the next", func() {
+				providers[0].Timeout = 10 * time.Millisecond
+				chain, _ = NewChain(providers)
+
+				mockFirst.EXPECT().Find(gomock.Any(), place).DoAndReturn(func(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+					<-ctx.Done()
+					return structs.CoOrdinates{}, ctx.Err()
+				})
+				mockSecond.EXPECT().Find(gomock.Any(), place).DoAndReturn(func(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+					_, hasDeadline := ctx.Deadline()
+					Expect(hasDeadline).To(BeFalse())
+					return chicago, nil
+				})
+
+				pos, err := chain.Find(context.Background(), place)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+			})
+		})
+	})
+
+	Context("Asking the providers at once", func() {
+		var (
+			chain Finder
+		)
+
+		BeforeEach(func() {
+			var err error
+			chain, err = NewParallel(providers)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		When("one provider finds the place", func() {
+			It("should return it and cancel the others", func() {
+				cancelled := make(chan error, 1)
+				mockFirst.EXPECT().Find(gomock.Any(), place).DoAndReturn(func(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+					<-ctx.Done()
+					cancelled <- ctx.Err()
+					return structs.CoOrdinates{}, ctx.Err()
+				})
+				mockSecond.EXPECT().Find(gomock.Any(), place).Return(chicago, nil)
+
+				pos, err := chain.Find(context.Background(), place)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+				Eventually(cancelled).Should(Receive(Equal(context.Canceled)))
+			})
+		})
+
+		When("every provider fails", func() {
+			It("should return why each failed in the order they were given", func() {
+				mockFirst.EXPECT().Find(gomock.Any(), place).DoAndReturn(func(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+					time.Sleep(10 * time.Millisecond)
+					return structs.CoOrdinates{}, errors.New("unavailable")
+				})
+				mockSecond.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, ErrNoData)
+
+				_, err := chain.Find(context.Background(), place)
+				Expect(err).To(Equal(&ChainError{Place: place, Errors: []ProviderError{
+					{Provider: "first", Err: errors.New("unavailable")},
+					{Provider: "second", Err: ErrNoData},
+				}}))
+			})
+		})
+
+		When("no provider finds the place but one finds it ambiguous", func() {
+			It("should return the ambiguity", func() {
+				ambiguous := &AmbiguousError{Place: place}
+				mockFirst.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, ErrNoData)
+				mockSecond.EXPECT().Find(gomock.Any(), place).Return(structs.CoOrdinates{}, ambiguous)
+
+				_, err := chain.Find(context.Background(), place)
+				Expect(err).To(Equal(ambiguous))
+			})
+		})
+	})
+
+	Context("Reading a chain's error", func() {
+		It("should unwrap to the first provider's error", func() {
+			err := &ChainError{Place: place, Errors: []ProviderError{
+				{Provider: "first", Err: &httpClient.StatusError{Code: http.StatusTooManyRequests}},
+				{Provider: "second", Err: ErrNoData},
+			}}
+			Expect(httpClient.IsRateLimited(err)).To(BeTrue())
+		})
+
+		It("should only be not found if no provider found the place", func() {
+			Expect(notFound(&ChainError{Place: place, Errors: []ProviderError{
+				{Provider: "first", Err: ErrCensusCountry},
+				{Provider: "second", Err: ErrNoData},
+			}})).To(BeTrue())
+
+			Expect(notFound(&ChainError{Place: place, Errors: []ProviderError{
+				{Provider: "first", Err: errors.New("unavailable")},
+				{Provider: "second", Err: ErrNoData},
+			}})).To(BeFalse())
+		})
+
+		It("should be not found however the errors are wrapped", func() {
+			Expect(notFound(fmt.Errorf("gazetteer: %w", ErrNoData))).To(BeTrue())
+			Expect(notFound(fmt.Errorf("geocoding: %w", &ChainError{Place: place, Errors: []ProviderError{
+				{Provider: "first", Err: fmt.Errorf("census: %w", ErrCensusCountry)},
+				{Provider: "second", Err: ProviderError{Provider: "inner", Err: ErrNoData}},
+			}}))).To(BeTrue())
+			Expect(notFound(errors.New("unavailable"))).To(BeFalse())
+		})
+	})
+})
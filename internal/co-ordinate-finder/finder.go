@@ -1,6 +1,7 @@
 package coOrdinateFinder
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,59 +12,147 @@ import (
 )
 
 const (
-	ErrorNoCity       = "You must supply a city"
+	ErrorNoCity       = "You must supply a city or postcode"
 	ErrorNoCountry    = "You must supply a country"
-	ErrorHTTPGet      = "HTTP GET error: %s"
+	ErrorHTTPGet      = "HTTP GET error: %w"
 	ErrorUnmarshall   = "Unmarshal error: %s"
 	ErrorNoData       = "No data found after unmarshal"
 	ErrorBadLatitude  = "Unrecognised latitude: %s"
 	ErrorBadLongitude = "Unrecognised longitude: %s"
+	ErrorBadBounds    = "Unrecognised bounding box: %v"
+	ErrorPolicy       = "Unknown geocoding policy %q, expected importance, city or strict"
+
+	// PolicyImportance takes the most important candidate, PolicyCity the most
+	// important which OpenStreetMap calls a city, falling back on the most
+	// important of any kind, and PolicyStrict fails if the candidates are
+	// more than one distinct place
+	PolicyImportance = "importance"
+	PolicyCity       = "city"
+	PolicyStrict     = "strict"
+
+	DefaultCandidates = 10
+)
+
+var (
+	// ErrNoData is returned, perhaps wrapped, when a provider finds no place
+	// matching the query
+	ErrNoData = errors.New(ErrorNoData)
 )
 
 //go:generate mockgen -destination=../mocks/mock-co-ordinate-finder.go -package=mocks . Finder
 type Finder interface {
-	Find(city, country string) (structs.CoOrdinates, error)
+	Find(ctx context.Context, place structs.Place) (structs.CoOrdinates, error)
+}
+
+// Config sets how many candidates are asked of Nominatim and the policy for
+// choosing between them
+type Config struct {
+	Policy     string
+	Candidates int
 }
 
 type finder struct {
-	web httpClient.Client
+	web    httpClient.Client
+	config Config
 }
 
-func (f finder) Find(city, country string) (structs.CoOrdinates, error) {
-	if len(city) < 1 {
+// Find makes a structured Nominatim query for the place, so the city, or the
+// postcode, is only matched within its state, when one is given, and its
+// country. The results are ranked by importance and one chosen by the
+// configured policy.
+func (f finder) Find(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+	if len(place.City) < 1 && len(place.Postcode) < 1 {
 		return structs.CoOrdinates{}, errors.New(ErrorNoCity)
 	}
 
-	if len(country) < 1 {
+	if len(place.Country) < 1 {
 		return structs.CoOrdinates{}, errors.New(ErrorNoCountry)
 	}
 
-	res, err := f.web.Get(fmt.Sprintf("https://nominatim.openstreetmap.org/search?q=%s,%s&format=json", url.QueryEscape(city), url.QueryEscape(country)))
+	query := url.Values{}
+	if len(place.Postcode) > 0 {
+		query.Set("postalcode", place.Postcode)
+	} else {
+		query.Set("city", place.City)
+	}
+	if len(place.State) > 0 {
+		query.Set("state", place.State)
+	}
+	query.Set("countrycodes", place.Country)
+	query.Set("addressdetails", "1")
+	query.Set("limit", strconv.Itoa(f.config.Candidates))
+	query.Set("format", "json")
+
+	body, err := f.web.Open(ctx, "https://nominatim.openstreetmap.org/search?"+query.Encode())
 	if err != nil {
-		return structs.CoOrdinates{}, fmt.Errorf(ErrorHTTPGet, err.Error())
+		return structs.CoOrdinates{}, fmt.Errorf(ErrorHTTPGet, err)
 	}
+	defer body.Close()
 
-	data := result{}
-	if err = json.Unmarshal([]byte(res), &data); err != nil {
-		return structs.CoOrdinates{}, fmt.Errorf(ErrorUnmarshall, err.Error())
+	items, err := f.decode(json.NewDecoder(body))
+	if err != nil {
+		return structs.CoOrdinates{}, err
 	}
 
-	if len(data) < 1 {
-		return structs.CoOrdinates{}, errors.New(ErrorNoData)
+	if len(items) < 1 {
+		return structs.CoOrdinates{}, ErrNoData
 	}
 
-	myLat, err := strconv.ParseFloat(data[0].Lat, 64)
-	if err != nil {
-		return structs.CoOrdinates{}, fmt.Errorf(ErrorBadLatitude, data[0].Lat)
+	candidates := make([]structs.Candidate, 0, len(items))
+	for _, item := range items {
+		candidate, err := item.candidate(place)
+		if err != nil {
+			return structs.CoOrdinates{}, err
+		}
+		candidates = append(candidates, candidate)
 	}
+	return choose(f.config.Policy, place, rank(candidates))
+}
 
-	myLon, err := strconv.ParseFloat(data[0].Lon, 64)
+// choose picks the candidate the policy says the place meant
+func choose(policy string, place structs.Place, candidates []structs.Candidate) (structs.CoOrdinates, error) {
+	switch policy {
+	case PolicyCity:
+		for _, candidate := range candidates {
+			if candidate.Class == "place" && candidate.Type == "city" {
+				return candidate.Position, nil
+			}
+		}
+	case PolicyStrict:
+		if distinct := distinct(candidates); len(distinct) > 1 {
+			return structs.CoOrdinates{}, &AmbiguousError{Place: place, Candidates: distinct}
+		}
+	}
+	return candidates[0].Position, nil
+}
+
+// decode reads the array of results, stopping at the configured number of
+// candidates should Nominatim return more. An error reading the body, such as
+// it being too large, is kept as the cause.
+func (f finder) decode(decoder *json.Decoder) ([]resultItem, error) {
+	token, err := decoder.Token()
 	if err != nil {
-		return structs.CoOrdinates{}, fmt.Errorf(ErrorBadLongitude, data[0].Lon)
+		return nil, decodeError(err)
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf(ErrorUnmarshall, "expected an array of results")
 	}
 
-	return structs.CoOrdinates{
-		Latitude:  myLat,
-		Longitude: myLon,
-	}, nil
+	items := make([]resultItem, 0)
+	for decoder.More() && len(items) < f.config.Candidates {
+		item := resultItem{}
+		if err := decoder.Decode(&item); err != nil {
+			return nil, decodeError(err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func decodeError(err error) error {
+	if httpClient.IsBodyTooLarge(err) {
+		return fmt.Errorf(ErrorHTTPGet, err)
+	}
+	return fmt.Errorf(ErrorUnmarshall, err.Error())
 }
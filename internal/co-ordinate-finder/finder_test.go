@@ -1,13 +1,21 @@
 package coOrdinateFinder
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
 	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestSuite(t *testing.T) {
@@ -15,19 +23,37 @@ func TestSuite(t *testing.T) {
 	RunSpecs(t, "Unit Tests")
 }
 
+func stream(data string) io.ReadCloser {
+	return ioutil.NopCloser(strings.NewReader(data))
+}
+
 var _ = Describe("Weather forecast handler", func() {
 	var (
 		mockController *gomock.Controller
 		mockHttpClient *mocks.MockClient
 		mockFinder     finder
+		newYork        structs.Place
+		springfield    structs.Place
+		springfields   string
 	)
 
 	BeforeEach(func() {
 		mockController = gomock.NewController(GinkgoT())
 		mockHttpClient = mocks.NewMockClient(mockController)
 		mockFinder = finder{
-			web: mockHttpClient,
+			web:    mockHttpClient,
+			config: Config{}.withDefaults(),
 		}
+		newYork = structs.Place{City: "New York", Country: "us"}
+		springfield = structs.Place{City: "springfield", Country: "us"}
+		springfields = `[
+			{"lat":"37.2", "lon":"-93.29", "display_name":"Springfield, Greene County, Missouri, United States", "class":"place", "type":"city", "importance":0.6,
+				"boundingbox":["37.1","37.3","-93.4","-93.1"], "address":{"state":"Missouri", "country_code":"us"}},
+			{"lat":"39.8", "lon":"-89.64", "display_name":"Springfield, Sangamon County, Illinois, United States", "class":"boundary", "type":"administrative", "importance":0.7,
+				"boundingbox":["39.7","39.9","-89.8","-89.5"], "address":{"state":"Illinois", "country_code":"us"}},
+			{"lat":"39.81", "lon":"-89.65", "display_name":"Springfield, Illinois, United States", "class":"place", "type":"town", "importance":0.5,
+				"address":{"state":"Illinois", "country_code":"us"}}
+		]`
 	})
 
 	AfterEach(func() {
@@ -37,62 +63,179 @@ var _ = Describe("Weather forecast handler", func() {
 	Context("Fetching the co-ordinates for a city", func() {
 		When("the length of the city is zero", func() {
 			It("should return an error", func() {
-				_, err := mockFinder.Find("", "USA")
+				_, err := mockFinder.Find(context.Background(), structs.Place{Country: "us"})
 				Expect(err).To(Equal(errors.New(ErrorNoCity)))
 			})
 		})
 
 		When("the length of the city is zero", func() {
 			It("should return an error", func() {
-				_, err := mockFinder.Find("New York", "")
+				_, err := mockFinder.Find(context.Background(), structs.Place{City: "New York"})
 				Expect(err).To(Equal(errors.New(ErrorNoCountry)))
 			})
 		})
 
+		When("the place has a city and country", func() {
+			It("should make a structured query for the city within the country", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), "https://nominatim.openstreetmap.org/search?addressdetails=1&city=New+York&countrycodes=us&format=json&limit=10").Return(stream(`[{"lat":"1.23", "lon":"1.23"}]`), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		When("the place also has a state", func() {
+			It("should make a structured query for the city within the state", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), "https://nominatim.openstreetmap.org/search?addressdetails=1&city=springfield&countrycodes=us&format=json&limit=10&state=illinois").Return(stream(`[{"lat":"1.23", "lon":"1.23"}]`), nil)
+				_, err := mockFinder.Find(context.Background(), structs.Place{City: "springfield", State: "illinois", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		When("the place is a postcode", func() {
+			It("should make a structured query for the postcode within the country", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), "https://nominatim.openstreetmap.org/search?addressdetails=1&countrycodes=us&format=json&limit=10&postalcode=60601").Return(stream(`[{"lat":"41.88", "lon":"-87.62"}]`), nil)
+				pos, err := mockFinder.Find(context.Background(), structs.Place{Postcode: "60601", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 41.88, Longitude: -87.62}))
+			})
+		})
+
 		When("there is an error calling the web service", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return("", errors.New("error carrying out GET request"))
-				_, err := mockFinder.Find("New York", "USA")
-				Expect(err).To(Equal(fmt.Errorf(ErrorHTTPGet, "error carrying out GET request")))
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(nil, errors.New("error carrying out GET request"))
+				_, err := mockFinder.Find(context.Background(), newYork)
+				Expect(err).To(Equal(fmt.Errorf(ErrorHTTPGet, errors.New("error carrying out GET request"))))
 			})
 		})
 
 		When("there is an error unmarshalling the response from the web service", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return("---", nil)
-				_, err := mockFinder.Find("New York", "USA")
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream("---"), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
 				Expect(err).To(Equal(fmt.Errorf(ErrorUnmarshall, "invalid character '-' in numeric literal")))
 			})
 		})
 
 		When("the data returned from the web service cannot be unmarshalled into usable information", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return("[]", nil)
-				_, err := mockFinder.Find("New York", "USA")
-				Expect(err).To(Equal(errors.New(ErrorNoData)))
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream("[]"), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
+				Expect(err).To(Equal(ErrNoData))
 			})
 		})
 
 		When("the data returned from the web service has a latitude which does not properly convert to a float64", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`[{"lat":"invalid-lat"}]`, nil)
-				_, err := mockFinder.Find("New York", "USA")
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`[{"lat":"invalid-lat"}]`), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
 				Expect(err).To(Equal(fmt.Errorf(ErrorBadLatitude, "invalid-lat")))
 			})
 		})
 
 		When("the data returned from the web service has a longitude which does not properly convert to a float64", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`[{"lat":"1.23", "lon":"invalid-lon"}]`, nil)
-				_, err := mockFinder.Find("New York", "USA")
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`[{"lat":"1.23", "lon":"invalid-lon"}]`), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
 				Expect(err).To(Equal(fmt.Errorf(ErrorBadLongitude, "invalid-lon")))
 			})
 		})
 
+		When("the data received is not an array of results", func() {
+			It("should return an error", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"lat":"1.23"}`), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
+				Expect(err).To(Equal(fmt.Errorf(ErrorUnmarshall, "expected an array of results")))
+			})
+		})
+
+		When("there are more results than the number of candidates", func() {
+			It("should not read the rest", func() {
+				mockFinder.config.Candidates = 1
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`[{"lat":"1.23", "lon":"4.56"}, ---`), nil)
+				pos, err := mockFinder.Find(context.Background(), newYork)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos.Latitude).To(Equal(1.23))
+				Expect(pos.Longitude).To(Equal(4.56))
+			})
+		})
+
+		When("a result has a bounding box which cannot be read", func() {
+			It("should return an error", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`[{"lat":"1.23", "lon":"4.56", "boundingbox":["1", "2"]}]`), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
+				Expect(err).To(Equal(fmt.Errorf(ErrorBadBounds, []string{"1", "2"})))
+			})
+		})
+
+		When("the body is too large", func() {
+			It("should return the error as the cause", func() {
+				tooLarge := &httpClient.BodyTooLargeError{URL: "http://example.org", Limit: 10}
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(ioutil.NopCloser(io.MultiReader(strings.NewReader(`[{"lat":`), iotest.ErrReader(tooLarge))), nil)
+				_, err := mockFinder.Find(context.Background(), newYork)
+				Expect(httpClient.IsBodyTooLarge(err)).To(BeTrue())
+			})
+		})
+
+		DescribeTable("choosing between several results",
+			func(policy string, expected structs.CoOrdinates) {
+				mockFinder.config.Policy = policy
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(springfields), nil)
+				pos, err := mockFinder.Find(context.Background(), springfield)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(expected))
+			},
+			Entry("by importance", PolicyImportance, structs.CoOrdinates{Latitude: 39.8, Longitude: -89.64}),
+			Entry("preferring cities", PolicyCity, structs.CoOrdinates{Latitude: 37.2, Longitude: -93.29}),
+		)
+
+		When("the policy is strict and the results are several distinct places", func() {
+			It("should return the distinct places ranked by importance", func() {
+				mockFinder.config.Policy = PolicyStrict
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(springfields), nil)
+				_, err := mockFinder.Find(context.Background(), springfield)
+
+				Expect(IsAmbiguous(err)).To(BeTrue())
+				Expect(err.Error()).To(Equal(fmt.Sprintf(ErrorAmbiguous, "springfield, US", 2)))
+				Expect(err.(*AmbiguousError).Candidates).To(Equal([]structs.Candidate{
+					{
+						Name:        "Springfield, Sangamon County, Illinois, United States",
+						Class:       "boundary",
+						Type:        "administrative",
+						Importance:  0.7,
+						BoundingBox: structs.BoundingBox{South: 39.7, North: 39.9, West: -89.8, East: -89.5},
+						Position:    structs.CoOrdinates{Latitude: 39.8, Longitude: -89.64},
+						Place:       structs.Place{City: "springfield", State: "illinois", Country: "us"},
+					},
+					{
+						Name:        "Springfield, Greene County, Missouri, United States",
+						Class:       "place",
+						Type:        "city",
+						Importance:  0.6,
+						BoundingBox: structs.BoundingBox{South: 37.1, North: 37.3, West: -93.4, East: -93.1},
+						Position:    structs.CoOrdinates{Latitude: 37.2, Longitude: -93.29},
+						Place:       structs.Place{City: "springfield", State: "missouri", Country: "us"},
+					},
+				}))
+			})
+		})
+
+		When("the policy is strict and the results are all the same place", func() {
+			It("should return the most important", func() {
+				mockFinder.config.Policy = PolicyStrict
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`[
+					{"lat":"41.87", "lon":"-87.62", "class":"place", "type":"city", "importance":0.6},
+					{"lat":"41.88", "lon":"-87.63", "class":"boundary", "type":"administrative", "importance":0.8, "boundingbox":["41.6","42.0","-87.9","-87.5"]}
+				]`), nil)
+				pos, err := mockFinder.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 41.88, Longitude: -87.63}))
+			})
+		})
+
 		When("the data can be unmarshalled and makes sense, and the lat and long are valid", func() {
 			It("should return the co-ordinates with no error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`[{"lat":"1.23", "lon":"1.23"}]`, nil)
-				pos, err := mockFinder.Find("New York", "USA")
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`[{"lat":"1.23", "lon":"1.23"}]`), nil)
+				pos, err := mockFinder.Find(context.Background(), newYork)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(pos.Longitude).To(Equal(1.23))
 				Expect(pos.Latitude).To(Equal(1.23))
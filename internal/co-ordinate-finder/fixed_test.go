@@ -0,0 +1,51 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fixed co-ordinate finder", func() {
+	var (
+		mockController *gomock.Controller
+		mockFinder     *mocks.MockFinder
+		fixed          Finder
+		chicago        structs.CoOrdinates
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockFinder = mocks.NewMockFinder(mockController)
+		chicago = structs.CoOrdinates{Latitude: 41.87, Longitude: -87.62}
+		fixed = NewFixed(mockFinder, map[structs.Place]structs.CoOrdinates{{City: "Chicago", Country: "US"}: chicago})
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	Context("Finding the co-ordinates for a city", func() {
+		When("the city has configured co-ordinates", func() {
+			It("should return them without asking the wrapped finder", func() {
+				pos, err := fixed.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(chicago))
+			})
+		})
+
+		When("the city has no configured co-ordinates", func() {
+			It("should ask the wrapped finder", func() {
+				boston := structs.CoOrdinates{Latitude: 42.36, Longitude: -71.06}
+				mockFinder.EXPECT().Find(gomock.Any(), structs.Place{City: "boston", Country: "us"}).Return(boston, nil)
+
+				pos, err := fixed.Find(context.Background(), structs.Place{City: "boston", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(boston))
+			})
+		})
+	})
+})
@@ -0,0 +1,232 @@
+package coOrdinateFinder
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	cityName "github.com/jddcode/tech-test-ennismore/internal/city-name"
+	"github.com/jddcode/tech-test-ennismore/internal/place"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrorGazetteer     = "Could not read the gazetteer: %s"
+	ErrorGazetteerLine = "Could not read the gazetteer on line %d"
+
+	// minimumPrefix is the shortest name matched as the start of a longer one,
+	// so a couple of letters do not match half the gazetteer
+	minimumPrefix = 4
+)
+
+var (
+	// embeddedCities is a subset of US cities in the layout of the GeoNames
+	// cities files, with approximate populations
+	//go:embed data/cities.txt
+	embeddedCities []byte
+)
+
+// GazetteerConfig sets where the gazetteer is read from, the GeoNames cities
+// file at Path or the embedded cities if it is not set, and the smallest
+// population of the cities it keeps. Policy is applied as for Nominatim.
+type GazetteerConfig struct {
+	Path          string
+	MinPopulation int64
+	Policy        string
+}
+
+type gazetteerEntry struct {
+	name, code string
+	state      string
+	country    string
+	position   structs.CoOrdinates
+	population int64
+}
+
+// gazetteer finds places offline from a list of cities indexed by their
+// folded names and alternate names. A city which matches no name exactly is
+// matched against the start of the names instead. The most populous
+// candidate is the best.
+type gazetteer struct {
+	policy  string
+	entries []gazetteerEntry
+	names   map[string][]int
+	sorted  []string
+}
+
+func (g *gazetteer) Find(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+	if err := ctx.Err(); err != nil {
+		return structs.CoOrdinates{}, err
+	}
+
+	if len(place.City) < 1 {
+		if len(place.Postcode) > 0 {
+			return structs.CoOrdinates{}, ErrNoData
+		}
+		return structs.CoOrdinates{}, errors.New(ErrorNoCity)
+	}
+
+	name := cityName.Fold(place.City)
+	matches := g.filter(g.names[name], place)
+	if len(matches) < 1 {
+		matches = g.filter(g.prefixed(name), place)
+	}
+
+	if len(matches) < 1 {
+		return structs.CoOrdinates{}, ErrNoData
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].population > matches[j].population
+	})
+
+	if g.policy == PolicyStrict && len(matches) > 1 {
+		candidates := make([]structs.Candidate, 0, len(matches))
+		for _, match := range matches {
+			candidates = append(candidates, match.candidate(place))
+		}
+		return structs.CoOrdinates{}, &AmbiguousError{Place: place, Candidates: candidates}
+	}
+	return matches[0].position, nil
+}
+
+// filter keeps the entries in the place's country and, for US places, its
+// state. The states of other countries are not known by name so are ignored.
+func (g *gazetteer) filter(indexes []int, place structs.Place) []gazetteerEntry {
+	matches := make([]gazetteerEntry, 0, len(indexes))
+	for _, index := range indexes {
+		entry := g.entries[index]
+		if len(place.Country) > 0 && entry.country != place.Country {
+			continue
+		}
+
+		if len(place.State) > 0 && place.Country == "us" && entry.state != place.State {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	return matches
+}
+
+// prefixed returns the entries with a name starting with the given name
+func (g *gazetteer) prefixed(name string) []int {
+	if len(name) < minimumPrefix {
+		return nil
+	}
+
+	indexes := make([]int, 0)
+	for at := sort.SearchStrings(g.sorted, name); at < len(g.sorted) && strings.HasPrefix(g.sorted[at], name); at++ {
+		indexes = append(indexes, g.names[g.sorted[at]]...)
+	}
+	return indexes
+}
+
+func (e gazetteerEntry) candidate(query structs.Place) structs.Candidate {
+	found := structs.Place{City: query.City, State: e.state, Country: e.country}
+	return structs.Candidate{
+		Name:        strings.Join([]string{e.name, found.State, strings.ToUpper(e.country)}, ", "),
+		Class:       "place",
+		Type:        strings.ToLower(e.code),
+		BoundingBox: structs.BoundingBox{South: e.position.Latitude, North: e.position.Latitude, West: e.position.Longitude, East: e.position.Longitude},
+		Position:    e.position,
+		Place:       found,
+	}
+}
+
+// readGazetteer indexes a tab separated GeoNames cities file, such as
+// cities15000.txt, skipping cities smaller than minPopulation
+func readGazetteer(reader io.Reader, minPopulation int64) (*gazetteer, error) {
+	g := &gazetteer{
+		names: make(map[string][]int),
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) < 1 {
+			continue
+		}
+
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 15 {
+			return nil, fmt.Errorf(ErrorGazetteerLine, line)
+		}
+
+		entry, err := gazetteerLine(fields)
+		if err != nil {
+			return nil, fmt.Errorf(ErrorGazetteerLine, line)
+		}
+
+		if entry.population < minPopulation {
+			continue
+		}
+
+		g.entries = append(g.entries, entry)
+		g.index(len(g.entries)-1, append([]string{fields[1], fields[2]}, strings.Split(fields[3], ",")...))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(ErrorGazetteer, err.Error())
+	}
+
+	for name := range g.names {
+		g.sorted = append(g.sorted, name)
+	}
+	sort.Strings(g.sorted)
+	return g, nil
+}
+
+// gazetteerLine reads the fields of a GeoNames line: the name, latitude,
+// longitude, feature code, country code, admin1 code and population
+func gazetteerLine(fields []string) (gazetteerEntry, error) {
+	lat, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return gazetteerEntry{}, err
+	}
+
+	lon, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return gazetteerEntry{}, err
+	}
+
+	population := int64(0)
+	if len(fields[14]) > 0 {
+		if population, err = strconv.ParseInt(fields[14], 10, 64); err != nil {
+			return gazetteerEntry{}, err
+		}
+	}
+
+	entry := gazetteerEntry{
+		name:       fields[1],
+		code:       fields[7],
+		state:      strings.ToLower(fields[10]),
+		country:    strings.ToLower(fields[8]),
+		position:   structs.CoOrdinates{Latitude: lat, Longitude: lon},
+		population: population,
+	}
+
+	if entry.country == place.DefaultCountry {
+		if state, err := place.State(fields[10], place.DefaultCountry); err == nil {
+			entry.state = state
+		}
+	}
+	return entry, nil
+}
+
+func (g *gazetteer) index(entry int, names []string) {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		folded := cityName.Fold(name)
+		if len(folded) < 1 || seen[folded] {
+			continue
+		}
+
+		seen[folded] = true
+		g.names[folded] = append(g.names[folded], entry)
+	}
+}
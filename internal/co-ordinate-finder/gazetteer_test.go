@@ -0,0 +1,111 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Gazetteer finder", func() {
+	const cities = "\tChicago\tChicago\tChi-Town,Windy City\t41.85003\t-87.65005\tP\tPPLA2\tUS\t\tIL\t031\t\t\t2720546\n" +
+		"\tSpringfield\tSpringfield\t\t39.80172\t-89.64371\tP\tPPLA\tUS\t\tIL\t167\t\t\t116565\n" +
+		"\tSpringfield\tSpringfield\t\t37.21533\t-93.29824\tP\tPPL\tUS\t\tMO\t077\t\t\t169176\n" +
+		"\tSaint-Étienne\tSaint-Etienne\t\t45.43389\t4.39\tP\tPPLA2\tFR\t\t84\t42\t\t\t171483\n" +
+		"\tSmallville\tSmallville\t\t39.5\t-98.5\tP\tPPL\tUS\t\tKS\t\t\t\t900\n"
+
+	var (
+		g *gazetteer
+	)
+
+	BeforeEach(func() {
+		var err error
+		g, err = readGazetteer(strings.NewReader(cities), 1000)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("Finding the co-ordinates for a place", func() {
+		DescribeTable("places in the gazetteer",
+			func(place structs.Place, expected structs.CoOrdinates) {
+				pos, err := g.Find(context.Background(), place)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos).To(Equal(expected))
+			},
+			Entry("an exact name", structs.Place{City: "Chicago", Country: "us"}, structs.CoOrdinates{Latitude: 41.85003, Longitude: -87.65005}),
+			Entry("an alternate name", structs.Place{City: "windy  city", Country: "us"}, structs.CoOrdinates{Latitude: 41.85003, Longitude: -87.65005}),
+			Entry("the start of a name", structs.Place{City: "chic", Country: "us"}, structs.CoOrdinates{Latitude: 41.85003, Longitude: -87.65005}),
+			Entry("an accented name", structs.Place{City: "SAINT-ETIENNE", Country: "fr"}, structs.CoOrdinates{Latitude: 45.43389, Longitude: 4.39}),
+			Entry("the most populous of several", structs.Place{City: "springfield", Country: "us"}, structs.CoOrdinates{Latitude: 37.21533, Longitude: -93.29824}),
+			Entry("a city in a state", structs.Place{City: "springfield", State: "illinois", Country: "us"}, structs.CoOrdinates{Latitude: 39.80172, Longitude: -89.64371}),
+		)
+
+		DescribeTable("places missing from the gazetteer",
+			func(place structs.Place) {
+				_, err := g.Find(context.Background(), place)
+				Expect(err).To(Equal(ErrNoData))
+			},
+			Entry("an unknown name", structs.Place{City: "atlantis", Country: "us"}),
+			Entry("a city in another country", structs.Place{City: "chicago", Country: "gb"}),
+			Entry("a city in another state", structs.Place{City: "chicago", State: "ohio", Country: "us"}),
+			Entry("a start too short to match", structs.Place{City: "chi", Country: "us"}),
+			Entry("a city smaller than the smallest kept", structs.Place{City: "smallville", Country: "us"}),
+			Entry("a postcode", structs.Place{Postcode: "60601", Country: "us"}),
+		)
+
+		When("the policy is strict and the city is ambiguous", func() {
+			It("should return the candidates", func() {
+				g.policy = PolicyStrict
+				_, err := g.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+				Expect(IsAmbiguous(err)).To(BeTrue())
+
+				ambiguous := &AmbiguousError{}
+				Expect(errors.As(err, &ambiguous)).To(BeTrue())
+				Expect(ambiguous.Candidates).To(HaveLen(2))
+				Expect(ambiguous.Candidates[0].Name).To(Equal("Springfield, missouri, US"))
+				Expect(ambiguous.Candidates[1].Place).To(Equal(structs.Place{City: "springfield", State: "illinois", Country: "us"}))
+			})
+		})
+
+		When("the request has been cancelled", func() {
+			It("should return its error", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				_, err := g.Find(ctx, structs.Place{City: "chicago", Country: "us"})
+				Expect(err).To(Equal(context.Canceled))
+			})
+		})
+	})
+
+	Context("Reading a gazetteer", func() {
+		When("a line is malformed", func() {
+			It("should return an error giving the line", func() {
+				_, err := readGazetteer(strings.NewReader(cities+"\tNowhere\tNowhere\t\tnorth\t-87\tP\tPPL\tUS\t\tIL\t\t\t\t10000\n"), 0)
+				Expect(err).To(Equal(fmt.Errorf(ErrorGazetteerLine, 6)))
+			})
+		})
+
+		When("no file is given", func() {
+			It("should use the embedded cities", func() {
+				finder, err := NewGazetteer(GazetteerConfig{})
+				Expect(err).ToNot(HaveOccurred())
+
+				pos, err := finder.Find(context.Background(), structs.Place{City: "chicago", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pos.Latitude).To(BeNumerically("~", 41.85, 0.1))
+				Expect(pos.Longitude).To(BeNumerically("~", -87.65, 0.1))
+			})
+		})
+
+		When("the file does not exist", func() {
+			It("should return an error", func() {
+				_, err := NewGazetteer(GazetteerConfig{Path: "/does/not/exist"})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
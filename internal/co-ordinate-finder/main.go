@@ -1,9 +1,172 @@
 package coOrdinateFinder
 
-import httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	fileStore "github.com/jddcode/tech-test-ennismore/internal/file-store"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"io"
+	"os"
+)
 
-func New() Finder {
+// New creates a Finder which asks Nominatim for up to config.Candidates
+// places, DefaultCandidates if it is not set, and chooses between them by
+// config.Policy, PolicyImportance if it is not set
+func New(web httpClient.Client, config Config) Finder {
 	return finder{
-		web: httpClient.New(),
+		web:    web,
+		config: config.withDefaults(),
 	}
 }
+
+// NewCensus creates a Finder which asks the US Census Bureau geocoder, which
+// only finds places in the US, configured as for New
+func NewCensus(web httpClient.Client, config Config) Finder {
+	return censusFinder{
+		web:    web,
+		config: config.withDefaults(),
+	}
+}
+
+// NewPhoton creates a Finder which asks Photon, configured as for New
+func NewPhoton(web httpClient.Client, config Config) Finder {
+	return photonFinder{
+		web:    web,
+		config: config.withDefaults(),
+	}
+}
+
+func (c Config) withDefaults() Config {
+	if len(c.Policy) < 1 {
+		c.Policy = PolicyImportance
+	}
+
+	if c.Candidates < 1 {
+		c.Candidates = DefaultCandidates
+	}
+	return c
+}
+
+// NewCached wraps a Finder with an in memory cache of its results
+func NewCached(finder Finder, config CacheConfig) Finder {
+	return &cachedFinder{
+		finder:    finder,
+		config:    config.withDefaults(),
+		positions: make(map[string]cachedPosition),
+		sweepAt:   minimumSweepSize,
+	}
+}
+
+// NewCachedPersistent wraps a Finder with a cache like NewCached which is
+// also written to the file at path so it survives a restart
+func NewCachedPersistent(finder Finder, config CacheConfig, path string) (PersistentFinder, error) {
+	store, err := fileStore.New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &cachedFinder{
+		finder:    finder,
+		config:    config.withDefaults(),
+		positions: make(map[string]cachedPosition),
+		store:     store,
+	}
+
+	if err := cached.load(); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return cached, nil
+}
+
+func (c CacheConfig) withDefaults() CacheConfig {
+	if c.TTL <= 0 {
+		c.TTL = DefaultCacheTTL
+	}
+
+	if c.NegativeTTL <= 0 {
+		c.NegativeTTL = DefaultCacheNegativeTTL
+	}
+	return c
+}
+
+// NewFixed wraps a Finder so the given places always resolve to the given
+// co-ordinates without asking the wrapped Finder
+func NewFixed(finder Finder, positions map[structs.Place]structs.CoOrdinates) Finder {
+	fixed := fixedFinder{
+		finder:    finder,
+		positions: make(map[string]structs.CoOrdinates, len(positions)),
+	}
+
+	for place, pos := range positions {
+		fixed.positions[place.Key()] = pos
+	}
+	return fixed
+}
+
+// NewCentroids wraps a Finder so US ZIP codes are answered from the table of
+// centroids in the file at path or, if it is not set, those embedded, without
+// asking the wrapped Finder
+func NewCentroids(finder Finder, path string) (Finder, error) {
+	var reader io.Reader = bytes.NewReader(embeddedZips)
+	if len(path) > 0 {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf(ErrorCentroids, err.Error())
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	positions, err := readCentroids(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return centroidFinder{
+		finder:    finder,
+		positions: positions,
+	}, nil
+}
+
+// NewGazetteer creates a Finder which needs no upstream service, answering
+// from the cities in a GeoNames cities file or, by default, those embedded
+func NewGazetteer(config GazetteerConfig) (Finder, error) {
+	var reader io.Reader = bytes.NewReader(embeddedCities)
+	if len(config.Path) > 0 {
+		file, err := os.Open(config.Path)
+		if err != nil {
+			return nil, fmt.Errorf(ErrorGazetteer, err.Error())
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	g, err := readGazetteer(reader, config.MinPopulation)
+	if err != nil {
+		return nil, err
+	}
+
+	g.policy = config.Policy
+	return g, nil
+}
+
+// NewChain creates a Finder which asks each provider in turn until one finds
+// the place, returning a ChainError giving why each failed if none does
+func NewChain(providers []Provider) (Finder, error) {
+	if len(providers) < 1 {
+		return nil, errors.New(ErrorNoProviders)
+	}
+	return chainFinder{providers: providers}, nil
+}
+
+// NewParallel creates a Finder like NewChain which asks every provider at
+// once, taking the place from whichever finds it first
+func NewParallel(providers []Provider) (Finder, error) {
+	if len(providers) < 1 {
+		return nil, errors.New(ErrorNoProviders)
+	}
+	return chainFinder{providers: providers, parallel: true}, nil
+}
@@ -0,0 +1,108 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// photonFinder asks Photon, a search engine over the OpenStreetMap data
+// Nominatim uses. Photon takes free text rather than a structured query, so
+// the results are filtered to the place's country and state afterwards.
+type photonFinder struct {
+	web    httpClient.Client
+	config Config
+}
+
+func (f photonFinder) Find(ctx context.Context, place structs.Place) (structs.CoOrdinates, error) {
+	if len(place.City) < 1 && len(place.Postcode) < 1 {
+		return structs.CoOrdinates{}, errors.New(ErrorNoCity)
+	}
+
+	if len(place.Country) < 1 {
+		return structs.CoOrdinates{}, errors.New(ErrorNoCountry)
+	}
+
+	query := url.Values{}
+	if len(place.Postcode) > 0 {
+		query.Set("q", place.Postcode)
+	} else {
+		query.Set("q", strings.Join(nonEmpty(place.City, place.State), ", "))
+		query.Set("osm_tag", "place")
+	}
+	query.Set("lang", "en")
+	query.Set("limit", strconv.Itoa(f.config.Candidates))
+
+	body, err := f.web.Open(ctx, "https://photon.komoot.io/api/?"+query.Encode())
+	if err != nil {
+		return structs.CoOrdinates{}, fmt.Errorf(ErrorHTTPGet, err)
+	}
+	defer body.Close()
+
+	response := photonResponse{}
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return structs.CoOrdinates{}, decodeError(err)
+	}
+
+	candidates := make([]structs.Candidate, 0, len(response.Features))
+	for _, feature := range response.Features {
+		if len(candidates) >= f.config.Candidates {
+			break
+		}
+
+		candidate, err := feature.candidate(place)
+		if err != nil {
+			return structs.CoOrdinates{}, err
+		}
+
+		if candidate.Place.Country != place.Country || (len(place.State) > 0 && candidate.Place.State != place.State) {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) < 1 {
+		return structs.CoOrdinates{}, ErrNoData
+	}
+	return choose(f.config.Policy, place, candidates)
+}
+
+// candidate reads the feature as a candidate for the place. Photon gives the
+// position as a longitude then a latitude, and the extent as the west, north,
+// east and south edges.
+func (p photonFeature) candidate(place structs.Place) (structs.Candidate, error) {
+	if len(p.Geometry.Coordinates) != 2 {
+		return structs.Candidate{}, fmt.Errorf(ErrorUnmarshall, "expected a point")
+	}
+
+	pos := structs.CoOrdinates{Latitude: p.Geometry.Coordinates[1], Longitude: p.Geometry.Coordinates[0]}
+	bounds := structs.BoundingBox{South: pos.Latitude, North: pos.Latitude, West: pos.Longitude, East: pos.Longitude}
+	if len(p.Properties.Extent) == 4 {
+		bounds = structs.BoundingBox{
+			South: p.Properties.Extent[3],
+			North: p.Properties.Extent[1],
+			West:  p.Properties.Extent[0],
+			East:  p.Properties.Extent[2],
+		}
+	} else if len(p.Properties.Extent) > 0 {
+		return structs.Candidate{}, fmt.Errorf(ErrorBadBounds, p.Properties.Extent)
+	}
+
+	place.Country = strings.ToLower(p.Properties.CountryCode)
+	place.State = strings.ToLower(p.Properties.State)
+
+	return structs.Candidate{
+		Name:        strings.Join(nonEmpty(p.Properties.Name, p.Properties.State, p.Properties.Country), ", "),
+		Class:       p.Properties.OsmKey,
+		Type:        p.Properties.OsmValue,
+		BoundingBox: bounds,
+		Position:    pos,
+		Place:       place,
+	}, nil
+}
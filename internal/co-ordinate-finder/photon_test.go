@@ -0,0 +1,107 @@
+package coOrdinateFinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Photon finder", func() {
+	var (
+		mockController *gomock.Controller
+		mockHttpClient *mocks.MockClient
+		photon         Finder
+		springfields   string
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockHttpClient = mocks.NewMockClient(mockController)
+		photon = NewPhoton(mockHttpClient, Config{})
+		springfields = `{"features":[
+			{"geometry":{"coordinates":[-2.1,51.3]}, "properties":{"name":"Springfield","state":"England","country":"United Kingdom","countrycode":"GB","osm_key":"place","osm_value":"village"}},
+			{"geometry":{"coordinates":[-89.64,39.8]}, "properties":{"name":"Springfield","state":"Illinois","country":"United States","countrycode":"US","osm_key":"place","osm_value":"city","extent":[-89.8,39.9,-89.5,39.7]}},
+			{"geometry":{"coordinates":[-93.29,37.2]}, "properties":{"name":"Springfield","state":"Missouri","country":"United States","countrycode":"US","osm_key":"place","osm_value":"city"}}
+		]}`
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	When("the place has a city", func() {
+		It("should search places and take the first in the country", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), "https://photon.komoot.io/api/?lang=en&limit=10&osm_tag=place&q=springfield").Return(stream(springfields), nil)
+
+			pos, err := photon.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 39.8, Longitude: -89.64}))
+		})
+	})
+
+	When("the place has a state", func() {
+		It("should search for both and take the first in the state", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), "https://photon.komoot.io/api/?lang=en&limit=10&osm_tag=place&q=springfield%2C+missouri").Return(stream(springfields), nil)
+
+			pos, err := photon.Find(context.Background(), structs.Place{City: "springfield", State: "missouri", Country: "us"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 37.2, Longitude: -93.29}))
+		})
+	})
+
+	When("the place is a postcode", func() {
+		It("should search for the postcode", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), "https://photon.komoot.io/api/?lang=en&limit=10&q=60601").
+				Return(stream(`{"features":[{"geometry":{"coordinates":[-87.62,41.88]}, "properties":{"countrycode":"US","state":"Illinois"}}]}`), nil)
+
+			pos, err := photon.Find(context.Background(), structs.Place{Postcode: "60601", Country: "us"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pos).To(Equal(structs.CoOrdinates{Latitude: 41.88, Longitude: -87.62}))
+		})
+	})
+
+	When("the policy is strict and the places are distinct", func() {
+		It("should return the candidates in the country", func() {
+			photon = NewPhoton(mockHttpClient, Config{Policy: PolicyStrict})
+			mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(springfields), nil)
+
+			_, err := photon.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+			ambiguous := &AmbiguousError{}
+			Expect(errors.As(err, &ambiguous)).To(BeTrue())
+			Expect(ambiguous.Candidates).To(HaveLen(2))
+			Expect(ambiguous.Candidates[0].Name).To(Equal("Springfield, Illinois, United States"))
+			Expect(ambiguous.Candidates[0].BoundingBox).To(Equal(structs.BoundingBox{South: 39.7, North: 39.9, West: -89.8, East: -89.5}))
+			Expect(ambiguous.Candidates[0].Place).To(Equal(structs.Place{City: "springfield", State: "illinois", Country: "us"}))
+		})
+	})
+
+	When("no place is in the country", func() {
+		It("should return an error", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(springfields), nil)
+
+			_, err := photon.Find(context.Background(), structs.Place{City: "springfield", Country: "au"})
+			Expect(err).To(Equal(ErrNoData))
+		})
+	})
+
+	When("a feature is not a point", func() {
+		It("should return an error", func() {
+			mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"features":[{"geometry":{"coordinates":[]}}]}`), nil)
+
+			_, err := photon.Find(context.Background(), structs.Place{City: "springfield", Country: "us"})
+			Expect(err).To(Equal(fmt.Errorf(ErrorUnmarshall, "expected a point")))
+		})
+	})
+
+	When("the place has no country", func() {
+		It("should return an error", func() {
+			_, err := photon.Find(context.Background(), structs.Place{City: "springfield"})
+			Expect(err).To(Equal(errors.New(ErrorNoCountry)))
+		})
+	})
+})
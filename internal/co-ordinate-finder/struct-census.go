@@ -0,0 +1,24 @@
+package coOrdinateFinder
+
+type censusResponse struct {
+	Result censusResult `json:"result"`
+}
+
+type censusResult struct {
+	AddressMatches []censusMatch `json:"addressMatches"`
+}
+
+type censusMatch struct {
+	MatchedAddress    string            `json:"matchedAddress"`
+	Coordinates       censusCoordinates `json:"coordinates"`
+	AddressComponents censusComponents  `json:"addressComponents"`
+}
+
+type censusCoordinates struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type censusComponents struct {
+	State string `json:"state"`
+}
@@ -0,0 +1,24 @@
+package coOrdinateFinder
+
+type photonResponse struct {
+	Features []photonFeature `json:"features"`
+}
+
+type photonFeature struct {
+	Geometry   photonGeometry   `json:"geometry"`
+	Properties photonProperties `json:"properties"`
+}
+
+type photonGeometry struct {
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type photonProperties struct {
+	Name        string    `json:"name"`
+	State       string    `json:"state"`
+	Country     string    `json:"country"`
+	CountryCode string    `json:"countrycode"`
+	OsmKey      string    `json:"osm_key"`
+	OsmValue    string    `json:"osm_value"`
+	Extent      []float64 `json:"extent"`
+}
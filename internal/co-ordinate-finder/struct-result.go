@@ -1,16 +1,22 @@
 package coOrdinateFinder
 
-type result []struct {
-	PlaceID     int      `json:"place_id"`
-	Licence     string   `json:"licence"`
-	OsmType     string   `json:"osm_type"`
-	OsmID       int      `json:"osm_id"`
-	Boundingbox []string `json:"boundingbox"`
-	Lat         string   `json:"lat"`
-	Lon         string   `json:"lon"`
-	DisplayName string   `json:"display_name"`
-	Class       string   `json:"class"`
-	Type        string   `json:"type"`
-	Importance  float64  `json:"importance"`
-	Icon        string   `json:"icon"`
+type resultItem struct {
+	PlaceID     int           `json:"place_id"`
+	Licence     string        `json:"licence"`
+	OsmType     string        `json:"osm_type"`
+	OsmID       int           `json:"osm_id"`
+	Boundingbox []string      `json:"boundingbox"`
+	Lat         string        `json:"lat"`
+	Lon         string        `json:"lon"`
+	DisplayName string        `json:"display_name"`
+	Class       string        `json:"class"`
+	Type        string        `json:"type"`
+	Importance  float64       `json:"importance"`
+	Icon        string        `json:"icon"`
+	Address     resultAddress `json:"address"`
+}
+
+type resultAddress struct {
+	State       string `json:"state"`
+	CountryCode string `json:"country_code"`
 }
@@ -0,0 +1,23 @@
+package fileStore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func New(path string) (Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf(ErrorOpen, err.Error())
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorOpen, err.Error())
+	}
+
+	return &store{
+		path: path,
+		file: file,
+	}, nil
+}
@@ -0,0 +1,214 @@
+package fileStore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	ErrorOpen    = "Could not open the store file: %s"
+	ErrorRead    = "Could not read the store file: %s"
+	ErrorWrite   = "Could not write to the store file: %s"
+	ErrorCompact = "Could not compact the store file: %s"
+	ErrorClosed  = "The store has been closed"
+)
+
+type Record struct {
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Stored  time.Time       `json:"stored,omitempty"`
+	Expires time.Time       `json:"expires"`
+	Deleted bool            `json:"deleted,omitempty"`
+}
+
+//go:generate mockgen -destination=../mocks/mock-file-store.go -package=mocks . Store
+type Store interface {
+	Load() (map[string]Record, error)
+	Append(record Record) error
+	Compact(records map[string]Record) error
+	Appended() int
+	Close() error
+}
+
+// store is an append-only log of JSON records, one per line. Every append is
+// synced to disk before returning. A trailing line left half written by a
+// crash is truncated when the log is next loaded, and compaction writes a
+// complete new log alongside the old one before renaming it into place, so
+// the file on disk is always readable.
+type store struct {
+	path     string
+	file     *os.File
+	appended int
+	lock     sync.Mutex
+}
+
+// Load replays the log and returns the latest record for every key which has
+// not been deleted. Expired records are returned as well, it is up to the
+// caller to decide what to do with them.
+func (s *store) Load() (map[string]Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil {
+		return nil, errors.New(ErrorClosed)
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf(ErrorRead, err.Error())
+	}
+
+	records := make(map[string]Record)
+	reader := bufio.NewReader(s.file)
+	valid := int64(0)
+	s.appended = 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf(ErrorRead, err.Error())
+		}
+
+		valid += int64(len(line))
+		record := Record{}
+		if err := json.Unmarshal(bytes.TrimSpace(line), &record); err != nil {
+			continue
+		}
+
+		s.appended++
+		if record.Deleted {
+			delete(records, record.Key)
+			continue
+		}
+		records[record.Key] = record
+	}
+
+	if err := s.file.Truncate(valid); err != nil {
+		return nil, fmt.Errorf(ErrorRead, err.Error())
+	}
+
+	if _, err := s.file.Seek(0, io.SeekEnd); err != nil {
+		return nil, fmt.Errorf(ErrorRead, err.Error())
+	}
+	return records, nil
+}
+
+func (s *store) Append(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf(ErrorWrite, err.Error())
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil {
+		return errors.New(ErrorClosed)
+	}
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf(ErrorWrite, err.Error())
+	}
+
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf(ErrorWrite, err.Error())
+	}
+	s.appended++
+	return nil
+}
+
+// Compact replaces the log with one holding only the given records
+func (s *store) Compact(records map[string]Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil {
+		return errors.New(ErrorClosed)
+	}
+
+	tempPath := s.path + ".tmp"
+	temp, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf(ErrorCompact, err.Error())
+	}
+
+	writer := bufio.NewWriter(temp)
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			temp.Close()
+			os.Remove(tempPath)
+			return fmt.Errorf(ErrorCompact, err.Error())
+		}
+		writer.Write(append(line, '\n'))
+	}
+
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf(ErrorCompact, err.Error())
+	}
+
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf(ErrorCompact, err.Error())
+	}
+	temp.Close()
+
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf(ErrorCompact, err.Error())
+	}
+	syncDir(filepath.Dir(s.path))
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf(ErrorCompact, err.Error())
+	}
+
+	s.file.Close()
+	s.file = file
+	s.appended = len(records)
+	return nil
+}
+
+// Appended is the number of records in the log, including those which have
+// since been replaced, which callers can use to decide when to compact
+func (s *store) Appended() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.appended
+}
+
+func (s *store) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func syncDir(path string) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer dir.Close()
+	dir.Sync()
+}
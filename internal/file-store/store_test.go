@@ -0,0 +1,137 @@
+package fileStore
+
+import (
+	"encoding/json"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Append-only file store", func() {
+	var (
+		dir     string
+		path    string
+		myStore Store
+		expires time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "file-store")
+		Expect(err).ToNot(HaveOccurred())
+
+		path = filepath.Join(dir, "store.log")
+		myStore, err = New(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		expires = time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	AfterEach(func() {
+		myStore.Close()
+		os.RemoveAll(dir)
+	})
+
+	reopen := func() Store {
+		Expect(myStore.Close()).To(Succeed())
+		reopened, err := New(path)
+		Expect(err).ToNot(HaveOccurred())
+		myStore = reopened
+		return reopened
+	}
+
+	Context("Writing and reloading records", func() {
+		When("records are appended and the store is reopened", func() {
+			It("should return the latest record for each key", func() {
+				Expect(myStore.Append(Record{Key: "first", Value: json.RawMessage(`"one"`), Expires: expires})).To(Succeed())
+				Expect(myStore.Append(Record{Key: "second", Value: json.RawMessage(`"two"`), Expires: expires})).To(Succeed())
+				Expect(myStore.Append(Record{Key: "first", Value: json.RawMessage(`"three"`), Expires: expires})).To(Succeed())
+
+				records, err := reopen().Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(2))
+				Expect(string(records["first"].Value)).To(Equal(`"three"`))
+				Expect(records["first"].Expires.Equal(expires)).To(BeTrue())
+				Expect(myStore.Appended()).To(Equal(3))
+			})
+		})
+
+		When("a record is deleted", func() {
+			It("should not be returned", func() {
+				Expect(myStore.Append(Record{Key: "first", Value: json.RawMessage(`"one"`), Expires: expires})).To(Succeed())
+				Expect(myStore.Append(Record{Key: "first", Deleted: true})).To(Succeed())
+
+				records, err := reopen().Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(BeEmpty())
+			})
+		})
+
+		When("the last line was only partly written before a crash", func() {
+			It("should ignore and truncate the partial line so later appends are readable", func() {
+				Expect(myStore.Append(Record{Key: "first", Value: json.RawMessage(`"one"`), Expires: expires})).To(Succeed())
+				Expect(myStore.Close()).To(Succeed())
+
+				file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+				Expect(err).ToNot(HaveOccurred())
+				file.Write([]byte(`{"key":"second","val`))
+				file.Close()
+
+				reopened := reopen()
+				records, err := reopened.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(1))
+
+				Expect(reopened.Append(Record{Key: "third", Value: json.RawMessage(`"three"`), Expires: expires})).To(Succeed())
+				records, err = reopen().Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveKey("first"))
+				Expect(records).To(HaveKey("third"))
+			})
+		})
+	})
+
+	Context("Compacting the store", func() {
+		When("the store is compacted", func() {
+			It("should only hold the given records", func() {
+				Expect(myStore.Append(Record{Key: "first", Value: json.RawMessage(`"one"`), Expires: expires})).To(Succeed())
+				Expect(myStore.Append(Record{Key: "first", Value: json.RawMessage(`"two"`), Expires: expires})).To(Succeed())
+				Expect(myStore.Append(Record{Key: "second", Value: json.RawMessage(`"three"`), Expires: expires})).To(Succeed())
+
+				Expect(myStore.Compact(map[string]Record{
+					"first": Record{Key: "first", Value: json.RawMessage(`"two"`), Expires: expires},
+				})).To(Succeed())
+				Expect(myStore.Appended()).To(Equal(1))
+
+				Expect(myStore.Append(Record{Key: "fourth", Value: json.RawMessage(`"four"`), Expires: expires})).To(Succeed())
+
+				records, err := reopen().Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(2))
+				Expect(string(records["first"].Value)).To(Equal(`"two"`))
+				Expect(records).To(HaveKey("fourth"))
+
+				_, err = os.Stat(path + ".tmp")
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("Using a closed store", func() {
+		When("a record is appended after the store is closed", func() {
+			It("should return an error", func() {
+				Expect(myStore.Close()).To(Succeed())
+				Expect(myStore.Append(Record{Key: "first"})).To(MatchError(ErrorClosed))
+			})
+		})
+	})
+})
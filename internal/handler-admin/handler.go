@@ -0,0 +1,166 @@
+package handlerAdmin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-admin/structs"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/cache"
+	"github.com/jddcode/tech-test-ennismore/internal/place"
+	coreStructs "github.com/jddcode/tech-test-ennismore/internal/structs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	PathCache = "/admin/cache"
+	PathWarm  = "/admin/warm"
+
+	ErrorUnauthorised     = "A valid admin token must be supplied as a bearer token"
+	ErrorMethodNotAllowed = "Method not allowed"
+	ErrorNotCached        = "The city is not cached: %s"
+	ErrorNoCities         = "Please supply a list of cities as a JSON body or a comma delimited URL parameter 'city'"
+	ErrorBadBody          = "Could not read the request body: %s"
+	ErrorMashallResult    = "Could not marshall result into valid json: %s"
+)
+
+type Cache interface {
+	Lookup(city string) (cache.Entry, error)
+	List() []cache.Entry
+	Delete(city string)
+	Purge()
+	Stats() cache.Stats
+}
+
+//go:generate mockgen -destination=../mocks/mock-warmer.go -package=mocks . Warmer
+type Warmer interface {
+	Warm(ctx context.Context, city string) error
+	Key(city string) string
+}
+
+type Handler interface {
+	Handle(w http.ResponseWriter, r *http.Request)
+}
+
+type handler struct {
+	cache  Cache
+	warmer Warmer
+	token  string
+}
+
+func (h handler) Handle(w http.ResponseWriter, r *http.Request) {
+	if !h.authorised(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(ErrorUnauthorised))
+		return
+	}
+
+	switch {
+	case r.URL.Path == PathWarm && r.Method == http.MethodPost:
+		h.warm(w, r)
+	case r.URL.Path == PathCache && r.Method == http.MethodGet:
+		h.list(w)
+	case r.URL.Path == PathCache && r.Method == http.MethodDelete:
+		h.cache.Purge()
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(r.URL.Path, PathCache+"/") && r.Method == http.MethodGet:
+		h.entry(w, strings.TrimPrefix(r.URL.Path, PathCache+"/"))
+	case strings.HasPrefix(r.URL.Path, PathCache+"/") && r.Method == http.MethodDelete:
+		h.cache.Delete(h.warmer.Key(strings.TrimPrefix(r.URL.Path, PathCache+"/")))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(ErrorMethodNotAllowed))
+	}
+}
+
+func (h handler) authorised(r *http.Request) bool {
+	supplied := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if len(h.token) < 1 || len(supplied) < 1 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(supplied), []byte(h.token)) == 1
+}
+
+func (h handler) list(w http.ResponseWriter) {
+	now := time.Now()
+	stats := h.cache.Stats()
+	output := structs.ResultEntries{
+		Entries:     make([]structs.ResultEntry, 0),
+		Bytes:       stats.Bytes,
+		Evictions:   stats.Evictions,
+		Expirations: stats.Expirations,
+	}
+
+	for _, entry := range h.cache.List() {
+		output.Entries = append(output.Entries, structs.ResultEntry{
+			City:             entry.City,
+			Stored:           entry.Stored,
+			Expires:          entry.Expires,
+			AgeSeconds:       int64(now.Sub(entry.Stored).Seconds()),
+			ExpiresInSeconds: int64(entry.Expires.Sub(now).Seconds()),
+			Stale:            !now.Before(entry.Expires),
+		})
+	}
+	h.write(w, output)
+}
+
+func (h handler) entry(w http.ResponseWriter, city string) {
+	entry, err := h.cache.Lookup(h.warmer.Key(city))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(fmt.Sprintf(ErrorNotCached, city)))
+		return
+	}
+	h.write(w, entry)
+}
+
+func (h handler) warm(w http.ResponseWriter, r *http.Request) {
+	request := structs.RequestWarm{}
+	if list := r.URL.Query().Get("city"); len(list) > 0 {
+		queries, err := place.ParseList(list, coreStructs.Place{Country: place.DefaultCountry})
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		for _, query := range queries {
+			request.Cities = append(request.Cities, query.Text)
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(fmt.Sprintf(ErrorBadBody, err.Error())))
+		return
+	}
+
+	if len(request.Cities) < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(ErrorNoCities))
+		return
+	}
+
+	output := structs.ResultWarm{}
+	for _, city := range request.Cities {
+		result := structs.ResultWarmCity{City: city}
+		if err := h.warmer.Warm(r.Context(), city); err != nil {
+			result.Error = err.Error()
+		}
+		output.Cities = append(output.Cities, result)
+	}
+	h.write(w, output)
+}
+
+func (h handler) write(w http.ResponseWriter, output interface{}) {
+	bytes, err := json.Marshal(output)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(fmt.Sprintf(ErrorMashallResult, err.Error())))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes)
+}
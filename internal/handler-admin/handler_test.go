@@ -0,0 +1,231 @@
+package handlerAdmin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/cache"
+	handlerStructs "github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Cache admin handler", func() {
+	var (
+		mockController *gomock.Controller
+		mockCache      *mocks.MockCache
+		mockWarmer     *mocks.MockWarmer
+		mockHandler    handler
+		keys           map[string]string
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockCache = mocks.NewMockCache(mockController)
+		mockWarmer = mocks.NewMockWarmer(mockController)
+		keys = make(map[string]string)
+		mockWarmer.EXPECT().Key(gomock.Any()).DoAndReturn(func(city string) string {
+			if key, exists := keys[city]; exists {
+				return key
+			}
+			return city
+		}).AnyTimes()
+		mockHandler = handler{
+			cache:  mockCache,
+			warmer: mockWarmer,
+			token:  "secret",
+		}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		mockReq := httptest.NewRequest(method, target, strings.NewReader(body))
+		mockReq.Header.Set("Authorization", "Bearer secret")
+		resp := httptest.NewRecorder()
+		mockHandler.Handle(resp, mockReq)
+		return resp
+	}
+
+	Context("Authorising requests", func() {
+		When("no token is supplied", func() {
+			It("should return unauthorised", func() {
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, httptest.NewRequest(http.MethodGet, PathCache, nil))
+
+				Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+				Expect(resp.Body.String()).To(Equal(ErrorUnauthorised))
+			})
+		})
+
+		When("the wrong token is supplied", func() {
+			It("should return unauthorised", func() {
+				mockReq := httptest.NewRequest(http.MethodDelete, PathCache, nil)
+				mockReq.Header.Set("Authorization", "Bearer wrong")
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		When("the handler has no token configured", func() {
+			It("should reject every request", func() {
+				mockHandler.token = ""
+				mockReq := httptest.NewRequest(http.MethodGet, PathCache, nil)
+				mockReq.Header.Set("Authorization", "Bearer ")
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			})
+		})
+	})
+
+	Context("Inspecting the cache", func() {
+		When("the cached cities are listed", func() {
+			It("should return each city with its age and expiry", func() {
+				now := time.Now()
+				mockCache.EXPECT().Stats().Return(cache.Stats{Entries: 2, Evictions: 3})
+				mockCache.EXPECT().List().Return([]cache.Entry{
+					cache.Entry{City: "fresh", Stored: now.Add(-time.Minute * 10), Expires: now.Add(time.Minute * 50)},
+					cache.Entry{City: "stale", Stored: now.Add(-time.Hour * 2), Expires: now.Add(-time.Hour)},
+				})
+
+				resp := request(http.MethodGet, PathCache, "")
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring(`"city":"fresh"`))
+				Expect(resp.Body.String()).To(ContainSubstring(`"ageSeconds":600,"expiresInSeconds":2999,"stale":false`))
+				Expect(resp.Body.String()).To(ContainSubstring(`"city":"stale"`))
+				Expect(resp.Body.String()).To(ContainSubstring(`"ageSeconds":7200,"expiresInSeconds":-3600,"stale":true`))
+				Expect(resp.Body.String()).To(ContainSubstring(`"evictions":3`))
+			})
+		})
+
+		When("a cached city is fetched", func() {
+			It("should return the raw entry", func() {
+				pointInTime, _ := time.Parse("2006-01-02 15:04:05", "2022-01-01 15:00:00")
+				mockCache.EXPECT().Lookup("testcity").Return(cache.Entry{
+					City:    "testcity",
+					Stored:  pointInTime,
+					Expires: pointInTime,
+					Predictions: []handlerStructs.ResultForecast{
+						handlerStructs.ResultForecast{Start: pointInTime, End: pointInTime, Prediction: "warm and sunny"},
+					},
+				}, nil)
+
+				resp := request(http.MethodGet, PathCache+"/testcity", "")
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(Equal(`{"city":"testcity","stored":"2022-01-01T15:00:00Z","expires":"2022-01-01T15:00:00Z","predictions":[{"starttime":"2022-01-01T15:00:00Z","endtime":"2022-01-01T15:00:00Z","description":"warm and sunny"}]}`))
+			})
+		})
+
+		When("a city cached under its grid cell is fetched", func() {
+			It("should look up the grid cell", func() {
+				keys["chicago"] = "lot/76,73"
+				mockCache.EXPECT().Lookup("lot/76,73").Return(cache.Entry{City: "lot/76,73"}, nil)
+
+				resp := request(http.MethodGet, PathCache+"/chicago", "")
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring(`"city":"lot/76,73"`))
+			})
+		})
+
+		When("a city which is not cached is fetched", func() {
+			It("should return not found", func() {
+				mockCache.EXPECT().Lookup("testcity").Return(cache.Entry{}, errors.New(cache.ErrorCacheMiss))
+
+				resp := request(http.MethodGet, PathCache+"/testcity", "")
+
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+				Expect(resp.Body.String()).To(Equal(fmt.Sprintf(ErrorNotCached, "testcity")))
+			})
+		})
+	})
+
+	Context("Purging the cache", func() {
+		When("a single city is purged", func() {
+			It("should delete it from the cache", func() {
+				mockCache.EXPECT().Delete("new york")
+
+				resp := request(http.MethodDelete, PathCache+"/new%20york", "")
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+			})
+		})
+
+		When("the whole cache is purged", func() {
+			It("should purge the cache", func() {
+				mockCache.EXPECT().Purge()
+
+				resp := request(http.MethodDelete, PathCache, "")
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+			})
+		})
+
+		When("an unsupported method is used", func() {
+			It("should return method not allowed", func() {
+				resp := request(http.MethodPut, PathCache, "")
+				Expect(resp.Code).To(Equal(http.StatusMethodNotAllowed))
+			})
+		})
+	})
+
+	Context("Pre-warming the cache", func() {
+		When("a list of cities is posted", func() {
+			It("should warm each city and report any failures", func() {
+				mockWarmer.EXPECT().Warm(gomock.Any(), "chicago").Return(nil)
+				mockWarmer.EXPECT().Warm(gomock.Any(), "nowhere").Return(errors.New("Could not find co-ordinates for city: nowhere"))
+
+				resp := request(http.MethodPost, PathWarm, `{"cities":["chicago","nowhere"]}`)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(Equal(`{"cities":[{"city":"chicago"},{"city":"nowhere","error":"Could not find co-ordinates for city: nowhere"}]}`))
+			})
+		})
+
+		When("the cities are given as a URL parameter", func() {
+			It("should warm each city", func() {
+				mockWarmer.EXPECT().Warm(gomock.Any(), "chicago").Return(nil)
+				mockWarmer.EXPECT().Warm(gomock.Any(), "boston").Return(nil)
+
+				resp := request(http.MethodPost, PathWarm+"?city=chicago,boston", "")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		When("a city in the URL parameter gives its state", func() {
+			It("should warm the city in that state", func() {
+				mockWarmer.EXPECT().Warm(gomock.Any(), "springfield|il").Return(nil)
+				mockWarmer.EXPECT().Warm(gomock.Any(), "boston").Return(nil)
+
+				resp := request(http.MethodPost, PathWarm+"?city=springfield|il,boston", "")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		When("no cities are given", func() {
+			It("should return an error", func() {
+				resp := request(http.MethodPost, PathWarm, `{"cities":[]}`)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Body.String()).To(Equal(ErrorNoCities))
+			})
+		})
+	})
+})
@@ -0,0 +1,11 @@
+package handlerAdmin
+
+// New creates the cache administration handler. Every request must carry the
+// token as a bearer token, so an empty token rejects every request.
+func New(cache Cache, warmer Warmer, token string) Handler {
+	return handler{
+		cache:  cache,
+		warmer: warmer,
+		token:  token,
+	}
+}
@@ -0,0 +1,5 @@
+package structs
+
+type RequestWarm struct {
+	Cities []string `json:"cities"`
+}
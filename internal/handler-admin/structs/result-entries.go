@@ -0,0 +1,19 @@
+package structs
+
+import "time"
+
+type ResultEntries struct {
+	Entries     []ResultEntry `json:"entries"`
+	Bytes       int64         `json:"bytes"`
+	Evictions   uint64        `json:"evictions"`
+	Expirations uint64        `json:"expirations"`
+}
+
+type ResultEntry struct {
+	City             string    `json:"city"`
+	Stored           time.Time `json:"stored"`
+	Expires          time.Time `json:"expires"`
+	AgeSeconds       int64     `json:"ageSeconds"`
+	ExpiresInSeconds int64     `json:"expiresInSeconds"`
+	Stale            bool      `json:"stale"`
+}
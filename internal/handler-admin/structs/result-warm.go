@@ -0,0 +1,10 @@
+package structs
+
+type ResultWarm struct {
+	Cities []ResultWarmCity `json:"cities"`
+}
+
+type ResultWarmCity struct {
+	City  string `json:"city"`
+	Error string `json:"error,omitempty"`
+}
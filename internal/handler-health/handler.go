@@ -0,0 +1,57 @@
+package handlerHealth
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-health/structs"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"net/http"
+)
+
+const (
+	Path = "/health"
+
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+
+	ErrorMashallResult = "Could not marshall result into valid json: %s"
+)
+
+//go:generate mockgen -destination=../mocks/mock-breakers.go -package=mocks . Breakers
+type Breakers interface {
+	States() []httpClient.BreakerStatus
+}
+
+type Handler interface {
+	Handle(w http.ResponseWriter, r *http.Request)
+}
+
+// handler reports the service as degraded while the circuit for any upstream
+// host is not closed. The service still answers from its cache when degraded,
+// so the response is always a 200 and only the body tells the two apart.
+type handler struct {
+	breakers Breakers
+}
+
+func (h handler) Handle(w http.ResponseWriter, r *http.Request) {
+	output := structs.ResultHealth{
+		Status:    StatusOK,
+		Upstreams: h.breakers.States(),
+	}
+
+	for _, upstream := range output.Upstreams {
+		if upstream.State != httpClient.StateClosed {
+			output.Status = StatusDegraded
+		}
+	}
+
+	bytes, err := json.Marshal(output)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(fmt.Sprintf(ErrorMashallResult, err.Error())))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes)
+}
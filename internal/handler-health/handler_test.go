@@ -0,0 +1,67 @@
+package handlerHealth
+
+import (
+	"github.com/golang/mock/gomock"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Health handler", func() {
+	var (
+		mockController *gomock.Controller
+		mockBreakers   *mocks.MockBreakers
+		mockHandler    Handler
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockBreakers = mocks.NewMockBreakers(mockController)
+		mockHandler = New(mockBreakers)
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	When("every circuit is closed", func() {
+		It("should report the service as ok", func() {
+			mockBreakers.EXPECT().States().Return([]httpClient.BreakerStatus{
+				httpClient.BreakerStatus{Host: "api.weather.gov", State: httpClient.StateClosed},
+			})
+
+			resp := httptest.NewRecorder()
+			mockHandler.Handle(resp, httptest.NewRequest(http.MethodGet, Path, nil))
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(ContainSubstring(`"status":"ok"`))
+			Expect(resp.Body.String()).To(ContainSubstring(`"host":"api.weather.gov","state":"closed"`))
+		})
+	})
+
+	When("a circuit is open", func() {
+		It("should report the service as degraded", func() {
+			mockBreakers.EXPECT().States().Return([]httpClient.BreakerStatus{
+				httpClient.BreakerStatus{Host: "api.weather.gov", State: httpClient.StateClosed},
+				httpClient.BreakerStatus{Host: "nominatim.openstreetmap.org", State: httpClient.StateOpen, Opens: 2},
+			})
+
+			resp := httptest.NewRecorder()
+			mockHandler.Handle(resp, httptest.NewRequest(http.MethodGet, Path, nil))
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(ContainSubstring(`"status":"degraded"`))
+			Expect(resp.Body.String()).To(ContainSubstring(`"state":"open"`))
+			Expect(resp.Body.String()).To(ContainSubstring(`"opens":2`))
+		})
+	})
+})
@@ -0,0 +1,9 @@
+package handlerHealth
+
+// New creates the health check handler, reporting on the circuit breakers in
+// front of the upstream services
+func New(breakers Breakers) Handler {
+	return handler{
+		breakers: breakers,
+	}
+}
@@ -0,0 +1,8 @@
+package structs
+
+import httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+
+type ResultHealth struct {
+	Status    string                     `json:"status"`
+	Upstreams []httpClient.BreakerStatus `json:"upstreams"`
+}
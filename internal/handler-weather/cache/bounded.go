@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	"sync"
+	"time"
+)
+
+type Policy string
+
+const (
+	PolicyLRU Policy = "lru"
+	PolicyLFU Policy = "lfu"
+)
+
+type Limits struct {
+	Policy     Policy
+	MaxEntries int
+	MaxBytes   int64
+}
+
+type boundedEntry struct {
+	Entry
+	size int64
+	hits uint64
+}
+
+// bounded keeps its entries in a list ordered from most to least recently
+// used. The LRU policy evicts from the back of the list, the LFU policy evicts
+// the entry with the fewest hits, using recency to break ties. The entry at
+// the front is never chosen by LFU so a new city is not evicted on arrival.
+type bounded struct {
+	content     map[string]*list.Element
+	order       *list.List
+	limits      Limits
+	config      Config
+	bytes       int64
+	evictions   uint64
+	expirations uint64
+	lock        sync.Mutex
+	stop        chan struct{}
+	once        sync.Once
+}
+
+func (b *bounded) Store(city string, predictions []structs.ResultForecast, expires time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var hits uint64
+	if existing, exists := b.content[city]; exists {
+		hits = existing.Value.(*boundedEntry).hits
+		b.remove(existing)
+	}
+
+	item := &boundedEntry{
+		Entry: Entry{
+			City:        city,
+			Stored:      time.Now(),
+			Expires:     capExpiry(expires, b.config.TTL),
+			Predictions: predictions,
+		},
+		size: entrySize(city, predictions),
+		hits: hits,
+	}
+	b.content[city] = b.order.PushFront(item)
+	b.bytes += item.size
+
+	for b.overLimit() {
+		b.remove(b.victim())
+		b.evictions++
+	}
+}
+
+func (b *bounded) Get(city string) ([]structs.ResultForecast, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	element, exists := b.content[city]
+	if !exists {
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+
+	item := element.Value.(*boundedEntry)
+	if !time.Now().Before(item.Expires) {
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+
+	item.hits++
+	b.order.MoveToFront(element)
+	return item.Predictions, nil
+}
+
+// GetStale returns the predictions for a city whether they are fresh or have
+// expired but are still within the stale window.
+func (b *bounded) GetStale(city string) ([]structs.ResultForecast, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	element, exists := b.content[city]
+	if !exists {
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+
+	item := element.Value.(*boundedEntry)
+	if !time.Now().Before(item.Expires.Add(b.config.StaleWindow)) {
+		b.remove(element)
+		b.expirations++
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+
+	item.hits++
+	b.order.MoveToFront(element)
+	return item.Predictions, nil
+}
+
+// Lookup returns the entry for a city as long as it can still be served,
+// without counting as a use of the entry for the eviction policy
+func (b *bounded) Lookup(city string) (Entry, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	element, exists := b.content[city]
+	if !exists || !time.Now().Before(element.Value.(*boundedEntry).Expires.Add(b.config.StaleWindow)) {
+		return Entry{}, errors.New(ErrorCacheMiss)
+	}
+	return element.Value.(*boundedEntry).Entry, nil
+}
+
+func (b *bounded) List() []Entry {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries := make([]Entry, 0, len(b.content))
+	for _, element := range b.content {
+		item := element.Value.(*boundedEntry).Entry
+		item.Predictions = nil
+		entries = append(entries, item)
+	}
+	return sortEntries(entries)
+}
+
+func (b *bounded) Delete(city string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if element, exists := b.content[city]; exists {
+		b.remove(element)
+	}
+}
+
+func (b *bounded) Purge() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.content = make(map[string]*list.Element)
+	b.order.Init()
+	b.bytes = 0
+}
+
+func (b *bounded) Stats() Stats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return Stats{
+		Entries:     len(b.content),
+		Bytes:       b.bytes,
+		Evictions:   b.evictions,
+		Expirations: b.expirations,
+	}
+}
+
+func (b *bounded) Close() {
+	b.once.Do(func() {
+		close(b.stop)
+	})
+}
+
+func (b *bounded) evictExpired() {
+	now := time.Now()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for _, element := range b.content {
+		if !now.Before(element.Value.(*boundedEntry).Expires.Add(b.config.StaleWindow)) {
+			b.remove(element)
+			b.expirations++
+		}
+	}
+}
+
+// overLimit never reports the only entry as over the limit, so a single
+// oversized forecast is still cached rather than evicted straight away
+func (b *bounded) overLimit() bool {
+	if b.order.Len() <= 1 {
+		return false
+	}
+
+	if b.limits.MaxEntries > 0 && b.order.Len() > b.limits.MaxEntries {
+		return true
+	}
+	return b.limits.MaxBytes > 0 && b.bytes > b.limits.MaxBytes
+}
+
+func (b *bounded) victim() *list.Element {
+	victim := b.order.Back()
+	if b.limits.Policy != PolicyLFU {
+		return victim
+	}
+
+	for element := victim.Prev(); element != nil && element != b.order.Front(); element = element.Prev() {
+		if element.Value.(*boundedEntry).hits < victim.Value.(*boundedEntry).hits {
+			victim = element
+		}
+	}
+	return victim
+}
+
+func (b *bounded) remove(element *list.Element) {
+	item := element.Value.(*boundedEntry)
+	b.order.Remove(element)
+	delete(b.content, item.City)
+	b.bytes -= item.size
+}
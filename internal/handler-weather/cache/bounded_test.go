@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"errors"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Bounded city cache", func() {
+	var (
+		predictions []structs.ResultForecast
+	)
+
+	BeforeEach(func() {
+		predictions = []structs.ResultForecast{
+			structs.ResultForecast{Prediction: "warm and sunny"},
+		}
+	})
+
+	Context("Evicting with the LRU policy", func() {
+		When("the maximum number of entries is exceeded", func() {
+			It("should evict the least recently used city", func() {
+				myCache := NewBounded(Config{TTL: time.Hour, JanitorInterval: time.Hour}, Limits{Policy: PolicyLRU, MaxEntries: 2})
+				defer myCache.Close()
+
+				myCache.Store("first", predictions, time.Time{})
+				myCache.Store("second", predictions, time.Time{})
+				_, err := myCache.Get("first")
+				Expect(err).ToNot(HaveOccurred())
+
+				myCache.Store("third", predictions, time.Time{})
+
+				_, err = myCache.Get("second")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				_, err = myCache.Get("first")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = myCache.Get("third")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(myCache.Stats().Entries).To(Equal(2))
+				Expect(myCache.Stats().Evictions).To(Equal(uint64(1)))
+			})
+		})
+
+		When("the maximum number of bytes is exceeded", func() {
+			It("should evict until the cache is back under the limit", func() {
+				size := entrySize("first", predictions)
+				myCache := NewBounded(Config{TTL: time.Hour, JanitorInterval: time.Hour}, Limits{Policy: PolicyLRU, MaxBytes: size * 2})
+				defer myCache.Close()
+
+				myCache.Store("first", predictions, time.Time{})
+				myCache.Store("secnd", predictions, time.Time{})
+				myCache.Store("third", predictions, time.Time{})
+
+				_, err := myCache.Get("first")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				Expect(myCache.Stats().Bytes).To(Equal(size * 2))
+				Expect(myCache.Stats().Evictions).To(Equal(uint64(1)))
+			})
+		})
+	})
+
+	Context("Evicting with the LFU policy", func() {
+		When("the maximum number of entries is exceeded", func() {
+			It("should evict the least frequently used city", func() {
+				myCache := NewBounded(Config{TTL: time.Hour, JanitorInterval: time.Hour}, Limits{Policy: PolicyLFU, MaxEntries: 2})
+				defer myCache.Close()
+
+				myCache.Store("popular", predictions, time.Time{})
+				myCache.Store("unpopular", predictions, time.Time{})
+				myCache.Get("popular")
+				myCache.Get("popular")
+				myCache.Get("unpopular")
+
+				myCache.Store("new", predictions, time.Time{})
+
+				_, err := myCache.Get("unpopular")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				_, err = myCache.Get("popular")
+				Expect(err).ToNot(HaveOccurred())
+				_, err = myCache.Get("new")
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Expiring entries", func() {
+		When("an entry has expired but is within the stale window", func() {
+			It("should be a miss for fresh data and a hit for stale data", func() {
+				myCache := NewBounded(Config{TTL: time.Hour, StaleWindow: time.Minute, JanitorInterval: time.Hour}, Limits{MaxEntries: 2})
+				defer myCache.Close()
+
+				myCache.Store("testcity", predictions, time.Now().Add(-time.Second))
+
+				_, err := myCache.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+
+				data, err := myCache.GetStale("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+			})
+		})
+
+		When("an entry has expired beyond the stale window", func() {
+			It("should return a cache miss and count the expiry", func() {
+				myCache := NewBounded(Config{TTL: time.Hour, StaleWindow: time.Minute, JanitorInterval: time.Hour}, Limits{MaxEntries: 2})
+				defer myCache.Close()
+
+				myCache.Store("testcity", predictions, time.Now().Add(-time.Minute*2))
+
+				_, err := myCache.GetStale("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				Expect(myCache.Stats().Entries).To(Equal(0))
+				Expect(myCache.Stats().Expirations).To(Equal(uint64(1)))
+				Expect(myCache.Stats().Evictions).To(Equal(uint64(0)))
+			})
+		})
+	})
+})
@@ -3,33 +3,198 @@ package cache
 import (
 	"errors"
 	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	"sort"
 	"sync"
+	"time"
+)
+
+const (
+	ErrorCacheMiss = "cache miss"
+
+	entryOverhead      = 128
+	predictionOverhead = 64
 )
 
 //go:generate mockgen -destination=../../mocks/mock-cache.go -package=mocks . Cache
 type Cache interface {
 	Get(city string) ([]structs.ResultForecast, error)
-	Store(city string, prediction []structs.ResultForecast)
+	GetStale(city string) ([]structs.ResultForecast, error)
+	Store(city string, prediction []structs.ResultForecast, expires time.Time)
+	Lookup(city string) (Entry, error)
+	List() []Entry
+	Delete(city string)
+	Purge()
+	Stats() Stats
+	Close()
+}
+
+// Entry describes a cached city. Lookup includes the predictions, List leaves
+// them out.
+type Entry struct {
+	City        string                   `json:"city"`
+	Stored      time.Time                `json:"stored"`
+	Expires     time.Time                `json:"expires"`
+	Predictions []structs.ResultForecast `json:"predictions,omitempty"`
+}
+
+type Stats struct {
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
 }
 
 type cache struct {
-	content map[string][]structs.ResultForecast
-	lock    sync.RWMutex
+	content     map[string]Entry
+	config      Config
+	expirations uint64
+	lock        sync.RWMutex
+	stop        chan struct{}
+	once        sync.Once
 }
 
-func (c *cache) Store(city string, predictions []structs.ResultForecast) {
+// Store keeps the predictions until the given expiry time, capped at the
+// cache's global TTL. A zero expiry uses the global TTL.
+func (c *cache) Store(city string, predictions []structs.ResultForecast, expires time.Time) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	c.content[city] = predictions
+	c.content[city] = Entry{
+		City:        city,
+		Stored:      time.Now(),
+		Expires:     capExpiry(expires, c.config.TTL),
+		Predictions: predictions,
+	}
+}
+
+func (c *cache) Get(city string) ([]structs.ResultForecast, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	val, exists := c.content[city]
+	if !exists || !time.Now().Before(val.Expires) {
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+	return val.Predictions, nil
+}
+
+// GetStale returns the predictions for a city whether they are fresh or have
+// expired but are still within the stale window.
+func (c *cache) GetStale(city string) ([]structs.ResultForecast, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	val, exists := c.content[city]
+	if !exists || !time.Now().Before(val.Expires.Add(c.config.StaleWindow)) {
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+	return val.Predictions, nil
 }
 
-func (c cache) Get(city string) ([]structs.ResultForecast, error) {
+// Lookup returns the entry for a city as long as it can still be served,
+// whether fresh or stale
+func (c *cache) Lookup(city string) (Entry, error) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 
 	val, exists := c.content[city]
-	if !exists {
-		return []structs.ResultForecast{}, errors.New("cache miss")
+	if !exists || !time.Now().Before(val.Expires.Add(c.config.StaleWindow)) {
+		return Entry{}, errors.New(ErrorCacheMiss)
 	}
 	return val, nil
 }
+
+func (c *cache) List() []Entry {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	entries := make([]Entry, 0, len(c.content))
+	for _, val := range c.content {
+		val.Predictions = nil
+		entries = append(entries, val)
+	}
+	return sortEntries(entries)
+}
+
+func (c *cache) Delete(city string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.content, city)
+}
+
+func (c *cache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.content = make(map[string]Entry)
+}
+
+func (c *cache) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	stats := Stats{
+		Entries:     len(c.content),
+		Expirations: c.expirations,
+	}
+	for city, val := range c.content {
+		stats.Bytes += entrySize(city, val.Predictions)
+	}
+	return stats
+}
+
+func (c *cache) Close() {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+}
+
+func (c *cache) evictExpired() {
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for city, val := range c.content {
+		if !now.Before(val.Expires.Add(c.config.StaleWindow)) {
+			delete(c.content, city)
+			c.expirations++
+		}
+	}
+}
+
+func capExpiry(expires time.Time, ttl time.Duration) time.Time {
+	latest := time.Now().Add(ttl)
+	if expires.IsZero() || expires.After(latest) {
+		return latest
+	}
+	return expires
+}
+
+func sortEntries(entries []Entry) []Entry {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].City < entries[j].City
+	})
+	return entries
+}
+
+func janitor(interval time.Duration, stop <-chan struct{}, evictExpired func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			evictExpired()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// entrySize is a rough estimate of the memory held by a cache entry, good
+// enough to bound the cache rather than an exact accounting
+func entrySize(city string, predictions []structs.ResultForecast) int64 {
+	size := int64(len(city)) + entryOverhead
+	for _, prediction := range predictions {
+		size += int64(len(prediction.Prediction)) + predictionOverhead
+	}
+	return size
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"errors"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("City cache", func() {
+	var (
+		myCache     *cache
+		predictions []structs.ResultForecast
+	)
+
+	BeforeEach(func() {
+		myCache = New(Config{TTL: time.Hour, JanitorInterval: time.Hour}).(*cache)
+		predictions = []structs.ResultForecast{
+			structs.ResultForecast{Prediction: "warm and sunny"},
+		}
+	})
+
+	AfterEach(func() {
+		myCache.Close()
+	})
+
+	Context("Reading and writing entries", func() {
+		When("a city has never been stored", func() {
+			It("should return a cache miss", func() {
+				_, err := myCache.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+			})
+		})
+
+		When("a city is stored with no expiry", func() {
+			It("should be returned and expire after the global TTL", func() {
+				myCache.Store("testcity", predictions, time.Time{})
+
+				data, err := myCache.Get("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+				Expect(myCache.content["testcity"].Expires).To(BeTemporally("~", time.Now().Add(time.Hour), time.Second))
+			})
+		})
+
+		When("a city is stored with an expiry beyond the global TTL", func() {
+			It("should be capped at the global TTL", func() {
+				myCache.Store("testcity", predictions, time.Now().Add(time.Hour*24))
+				Expect(myCache.content["testcity"].Expires).To(BeTemporally("~", time.Now().Add(time.Hour), time.Second))
+			})
+		})
+
+		When("a city has expired", func() {
+			It("should return a cache miss", func() {
+				myCache.Store("testcity", predictions, time.Now().Add(-time.Second))
+
+				_, err := myCache.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+			})
+		})
+	})
+
+	Context("Reading stale entries", func() {
+		When("a city has expired but is within the stale window", func() {
+			It("should be returned as stale data", func() {
+				myCache.Store("testcity", predictions, time.Now().Add(-time.Second))
+
+				data, err := myCache.GetStale("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+			})
+		})
+
+		When("a city has expired beyond the stale window", func() {
+			It("should return a cache miss", func() {
+				myCache.Store("testcity", predictions, time.Now().Add(-DefaultStaleWindow))
+
+				_, err := myCache.GetStale("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+			})
+		})
+	})
+
+	Context("Evicting expired entries", func() {
+		When("the janitor runs", func() {
+			It("should remove expired entries and keep live ones", func() {
+				janitorCache := New(Config{TTL: time.Hour, StaleWindow: -1, JanitorInterval: time.Millisecond * 10}).(*cache)
+				defer janitorCache.Close()
+
+				janitorCache.Store("expired", predictions, time.Now().Add(time.Millisecond*5))
+				janitorCache.Store("live", predictions, time.Time{})
+
+				Eventually(func() int {
+					janitorCache.lock.RLock()
+					defer janitorCache.lock.RUnlock()
+					return len(janitorCache.content)
+				}).Should(Equal(1))
+
+				_, err := janitorCache.Get("live")
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Administering entries", func() {
+		When("a city is looked up", func() {
+			It("should return its entry with the times it was stored and expires", func() {
+				myCache.Store("testcity", predictions, time.Time{})
+
+				entry, err := myCache.Lookup("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entry.City).To(Equal("testcity"))
+				Expect(entry.Predictions).To(Equal(predictions))
+				Expect(entry.Stored.IsZero()).To(BeFalse())
+				Expect(entry.Expires.After(entry.Stored)).To(BeTrue())
+			})
+		})
+
+		When("the cities are listed", func() {
+			It("should return every entry in order without the predictions", func() {
+				myCache.Store("second", predictions, time.Time{})
+				myCache.Store("first", predictions, time.Time{})
+
+				entries := myCache.List()
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[0].City).To(Equal("first"))
+				Expect(entries[1].City).To(Equal("second"))
+				Expect(entries[0].Predictions).To(BeNil())
+			})
+		})
+
+		When("a city is deleted", func() {
+			It("should no longer be cached", func() {
+				myCache.Store("testcity", predictions, time.Time{})
+				myCache.Store("othercity", predictions, time.Time{})
+				myCache.Delete("testcity")
+
+				_, err := myCache.Lookup("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				_, err = myCache.Get("othercity")
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		When("the cache is purged", func() {
+			It("should hold no entries", func() {
+				myCache.Store("first", predictions, time.Time{})
+				myCache.Store("second", predictions, time.Time{})
+				myCache.Purge()
+
+				Expect(myCache.List()).To(BeEmpty())
+				Expect(myCache.Stats().Entries).To(Equal(0))
+			})
+		})
+	})
+})
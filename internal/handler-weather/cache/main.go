@@ -1,9 +1,119 @@
 package cache
 
-import "github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+import (
+	"container/list"
+	fileStore "github.com/jddcode/tech-test-ennismore/internal/file-store"
+	redisClient "github.com/jddcode/tech-test-ennismore/internal/redis-client"
+	"time"
+)
 
-func New() Cache {
-	return &cache{
-		content: make(map[string][]structs.ResultForecast),
+const (
+	DefaultTTL             = time.Hour
+	DefaultStaleWindow     = time.Minute * 30
+	DefaultJanitorInterval = time.Minute
+)
+
+// Config controls how long entries live. An entry is fresh for up to TTL,
+// then may still be served as stale for StaleWindow before it is removed by
+// the janitor, which runs every JanitorInterval. Zero values use the defaults
+// and a negative StaleWindow disables stale serving.
+type Config struct {
+	TTL             time.Duration
+	StaleWindow     time.Duration
+	JanitorInterval time.Duration
+}
+
+// New creates an in memory cache with no limit on its size. The background
+// janitor runs until Close is called.
+func New(config Config) Cache {
+	config = config.withDefaults()
+	c := &cache{
+		content: make(map[string]Entry),
+		config:  config,
+		stop:    make(chan struct{}),
+	}
+	go janitor(config.JanitorInterval, c.stop, c.evictExpired)
+	return c
+}
+
+// NewBounded creates an in memory cache like New which also holds no more
+// than the given number of entries and approximate bytes, evicting according
+// to the given policy when either limit is exceeded. A zero limit is ignored.
+func NewBounded(config Config, limits Limits) Cache {
+	config = config.withDefaults()
+	if limits.Policy != PolicyLFU {
+		limits.Policy = PolicyLRU
+	}
+
+	b := &bounded{
+		content: make(map[string]*list.Element),
+		order:   list.New(),
+		limits:  limits,
+		config:  config,
+		stop:    make(chan struct{}),
+	}
+	go janitor(config.JanitorInterval, b.stop, b.evictExpired)
+	return b
+}
+
+func (c Config) withDefaults() Config {
+	if c.TTL <= 0 {
+		c.TTL = DefaultTTL
+	}
+
+	if c.StaleWindow == 0 {
+		c.StaleWindow = DefaultStaleWindow
+	}
+
+	if c.StaleWindow < 0 {
+		c.StaleWindow = 0
+	}
+
+	if c.JanitorInterval <= 0 {
+		c.JanitorInterval = DefaultJanitorInterval
+	}
+	return c
+}
+
+// NewPersistent creates an in memory cache like New which also writes every
+// entry to the file at path, reloading any entries which have not expired
+// from it when the cache is created.
+func NewPersistent(config Config, path string) (Cache, error) {
+	config = config.withDefaults()
+	store, err := fileStore.New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &persistent{
+		memory: &cache{
+			content: make(map[string]Entry),
+			config:  config,
+		},
+		store: store,
+		stop:  make(chan struct{}),
+	}
+
+	if err := p.load(); err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	go janitor(config.JanitorInterval, p.stop, p.evictExpired)
+	return p, nil
+}
+
+// NewRedis creates a cache held in a redis compatible server, shared by every
+// replica using the same namespace. The janitor interval is not used as the
+// server expires keys itself.
+func NewRedis(config Config, client redisClient.Client, namespace string) Cache {
+	if len(namespace) < 1 {
+		namespace = DefaultRedisNamespace
+	}
+
+	return &redisCache{
+		client:    client,
+		namespace: namespace,
+		config:    config.withDefaults(),
 	}
 }
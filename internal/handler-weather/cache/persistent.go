@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"encoding/json"
+	fileStore "github.com/jddcode/tech-test-ennismore/internal/file-store"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	compactMinimumRecords = 64
+)
+
+// persistent keeps its entries in memory, the same as cache, and writes each
+// one to an append-only file so they survive a restart. The file is compacted
+// by the janitor once it holds more than twice as many records as the cache.
+// A failure to write the file is logged, as the entries are still served
+// from memory.
+type persistent struct {
+	memory *cache
+	store  fileStore.Store
+	writes sync.Mutex
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func (p *persistent) Store(city string, predictions []structs.ResultForecast, expires time.Time) {
+	item := Entry{
+		City:        city,
+		Stored:      time.Now(),
+		Expires:     capExpiry(expires, p.memory.config.TTL),
+		Predictions: predictions,
+	}
+
+	record, err := p.record(item)
+	if err != nil {
+		return
+	}
+
+	p.writes.Lock()
+	defer p.writes.Unlock()
+
+	p.memory.lock.Lock()
+	p.memory.content[city] = item
+	p.memory.lock.Unlock()
+
+	if err := p.store.Append(record); err != nil {
+		log.Printf("Could not persist the forecast for %s: %s", city, err.Error())
+	}
+}
+
+func (p *persistent) Get(city string) ([]structs.ResultForecast, error) {
+	return p.memory.Get(city)
+}
+
+func (p *persistent) GetStale(city string) ([]structs.ResultForecast, error) {
+	return p.memory.GetStale(city)
+}
+
+func (p *persistent) Lookup(city string) (Entry, error) {
+	return p.memory.Lookup(city)
+}
+
+func (p *persistent) List() []Entry {
+	return p.memory.List()
+}
+
+func (p *persistent) Delete(city string) {
+	p.writes.Lock()
+	defer p.writes.Unlock()
+
+	p.memory.Delete(city)
+	err := p.store.Append(fileStore.Record{
+		Key:     city,
+		Deleted: true,
+	})
+	if err != nil {
+		log.Printf("Could not persist the removal of the forecast for %s: %s", city, err.Error())
+	}
+}
+
+func (p *persistent) Purge() {
+	p.writes.Lock()
+	defer p.writes.Unlock()
+
+	p.memory.Purge()
+	if err := p.store.Compact(map[string]fileStore.Record{}); err != nil {
+		log.Printf("Could not persist the purge of the forecast cache: %s", err.Error())
+	}
+}
+
+func (p *persistent) Stats() Stats {
+	return p.memory.Stats()
+}
+
+func (p *persistent) Close() {
+	p.once.Do(func() {
+		close(p.stop)
+		p.store.Close()
+	})
+}
+
+func (p *persistent) load() error {
+	records, err := p.store.Load()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	p.memory.lock.Lock()
+	defer p.memory.lock.Unlock()
+	for city, record := range records {
+		if !now.Before(record.Expires.Add(p.memory.config.StaleWindow)) {
+			continue
+		}
+
+		predictions := make([]structs.ResultForecast, 0)
+		if err := json.Unmarshal(record.Value, &predictions); err != nil {
+			continue
+		}
+
+		p.memory.content[city] = Entry{
+			City:        city,
+			Stored:      record.Stored,
+			Expires:     record.Expires,
+			Predictions: predictions,
+		}
+	}
+	return nil
+}
+
+func (p *persistent) evictExpired() {
+	p.memory.evictExpired()
+
+	p.writes.Lock()
+	defer p.writes.Unlock()
+
+	p.memory.lock.RLock()
+	if p.store.Appended() <= len(p.memory.content)*2+compactMinimumRecords {
+		p.memory.lock.RUnlock()
+		return
+	}
+
+	records := make(map[string]fileStore.Record)
+	for city, item := range p.memory.content {
+		if record, err := p.record(item); err == nil {
+			records[city] = record
+		}
+	}
+	p.memory.lock.RUnlock()
+
+	if err := p.store.Compact(records); err != nil {
+		log.Printf("Could not compact the forecast cache: %s", err.Error())
+	}
+}
+
+func (p *persistent) record(item Entry) (fileStore.Record, error) {
+	value, err := json.Marshal(item.Predictions)
+	if err != nil {
+		return fileStore.Record{}, err
+	}
+
+	return fileStore.Record{
+		Key:     item.City,
+		Value:   value,
+		Stored:  item.Stored,
+		Expires: item.Expires,
+	}, nil
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	fileStore "github.com/jddcode/tech-test-ennismore/internal/file-store"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Persistent city cache", func() {
+	var (
+		dir         string
+		path        string
+		config      Config
+		predictions []structs.ResultForecast
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "persistent-cache")
+		Expect(err).ToNot(HaveOccurred())
+
+		path = filepath.Join(dir, "cache.log")
+		config = Config{TTL: time.Hour, StaleWindow: time.Minute, JanitorInterval: time.Hour}
+		pointInTime, _ := time.Parse("2006-01-02 15:04:05", "2022-01-01 15:00:00")
+		predictions = []structs.ResultForecast{
+			structs.ResultForecast{
+				Start:      pointInTime,
+				End:        pointInTime,
+				Prediction: "warm and sunny",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("Restarting the service", func() {
+		When("a live entry was stored before the restart", func() {
+			It("should still be returned afterwards", func() {
+				myCache, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				myCache.Store("testcity", predictions, time.Time{})
+				myCache.Close()
+
+				reopened, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer reopened.Close()
+
+				data, err := reopened.Get("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+			})
+		})
+
+		When("an entry expired but is within the stale window", func() {
+			It("should be reloaded as stale data", func() {
+				myCache, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				myCache.Store("testcity", predictions, time.Now().Add(-time.Second))
+				myCache.Close()
+
+				reopened, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer reopened.Close()
+
+				_, err = reopened.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				data, err := reopened.GetStale("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+			})
+		})
+
+		When("an entry expired beyond the stale window", func() {
+			It("should not be reloaded", func() {
+				myCache, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				myCache.Store("testcity", predictions, time.Now().Add(-time.Minute*2))
+				myCache.Close()
+
+				reopened, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer reopened.Close()
+
+				Expect(reopened.Stats().Entries).To(Equal(0))
+			})
+		})
+	})
+
+	Context("Compacting the file", func() {
+		When("a city has been stored many more times than there are entries", func() {
+			It("should compact the file and keep the latest entry", func() {
+				myCache, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+
+				for i := 0; i < compactMinimumRecords+10; i++ {
+					myCache.Store("testcity", predictions, time.Time{})
+				}
+
+				persistentCache := myCache.(*persistent)
+				persistentCache.evictExpired()
+				Expect(persistentCache.store.Appended()).To(Equal(1))
+				myCache.Close()
+
+				reopened, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer reopened.Close()
+
+				data, err := reopened.Get("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+			})
+		})
+	})
+
+	Context("Administering entries", func() {
+		When("a city is deleted before a restart", func() {
+			It("should not be reloaded", func() {
+				myCache, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				myCache.Store("testcity", predictions, time.Time{})
+				myCache.Store("othercity", predictions, time.Time{})
+				myCache.Delete("testcity")
+				myCache.Close()
+
+				reopened, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer reopened.Close()
+
+				_, err = reopened.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				_, err = reopened.Get("othercity")
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		When("the cache is purged before a restart", func() {
+			It("should start empty", func() {
+				myCache, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				myCache.Store("testcity", predictions, time.Time{})
+				myCache.Purge()
+				myCache.Close()
+
+				reopened, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer reopened.Close()
+
+				Expect(reopened.List()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("Failing to write the file", func() {
+		When("the file can no longer be written", func() {
+			It("should log each failure and still serve the entries from memory", func() {
+				logged := &bytes.Buffer{}
+				log.SetOutput(logged)
+				defer log.SetOutput(os.Stderr)
+
+				myCache, err := NewPersistent(config, path)
+				Expect(err).ToNot(HaveOccurred())
+				defer myCache.Close()
+				Expect(myCache.(*persistent).store.Close()).To(Succeed())
+
+				myCache.Store("testcity", predictions, time.Time{})
+				data, err := myCache.Get("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+
+				myCache.Delete("testcity")
+				myCache.Purge()
+				Expect(logged.String()).To(ContainSubstring("Could not persist the forecast for testcity: " + fileStore.ErrorClosed))
+				Expect(logged.String()).To(ContainSubstring("Could not persist the removal of the forecast for testcity: " + fileStore.ErrorClosed))
+				Expect(logged.String()).To(ContainSubstring("Could not persist the purge of the forecast cache: " + fileStore.ErrorClosed))
+			})
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	redisClient "github.com/jddcode/tech-test-ennismore/internal/redis-client"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultRedisNamespace = "tech-test:forecast:"
+)
+
+// redisCache shares its entries between every replica of the service by
+// keeping them in a redis compatible server. Each key lives for the TTL plus
+// the stale window so the server removes it once it can no longer be served,
+// and an entry which could not be served at all is removed rather than
+// stored. Any error talking to the server is treated as a cache miss.
+type redisCache struct {
+	client    redisClient.Client
+	namespace string
+	config    Config
+}
+
+func (r *redisCache) Store(city string, predictions []structs.ResultForecast, expires time.Time) {
+	expires = capExpiry(expires, r.config.TTL)
+	value, err := json.Marshal(Entry{
+		City:        city,
+		Stored:      time.Now(),
+		Expires:     expires,
+		Predictions: predictions,
+	})
+	if err != nil {
+		return
+	}
+
+	ttl := time.Until(expires) + r.config.StaleWindow
+	if ttl <= 0 {
+		r.client.Del(r.namespace + city)
+		return
+	}
+	r.client.Set(r.namespace+city, string(value), ttl)
+}
+
+func (r *redisCache) Get(city string) ([]structs.ResultForecast, error) {
+	stored, err := r.get(city)
+	if err != nil {
+		return []structs.ResultForecast{}, err
+	}
+
+	if !time.Now().Before(stored.Expires) {
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+	return stored.Predictions, nil
+}
+
+func (r *redisCache) GetStale(city string) ([]structs.ResultForecast, error) {
+	stored, err := r.get(city)
+	if err != nil {
+		return []structs.ResultForecast{}, err
+	}
+
+	if !time.Now().Before(stored.Expires.Add(r.config.StaleWindow)) {
+		return []structs.ResultForecast{}, errors.New(ErrorCacheMiss)
+	}
+	return stored.Predictions, nil
+}
+
+func (r *redisCache) Lookup(city string) (Entry, error) {
+	stored, err := r.get(city)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if !time.Now().Before(stored.Expires.Add(r.config.StaleWindow)) {
+		return Entry{}, errors.New(ErrorCacheMiss)
+	}
+	return stored, nil
+}
+
+func (r *redisCache) List() []Entry {
+	keys, err := r.client.Scan(r.namespace + "*")
+	if err != nil {
+		return []Entry{}
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		stored, err := r.get(strings.TrimPrefix(key, r.namespace))
+		if err != nil {
+			continue
+		}
+
+		stored.Predictions = nil
+		entries = append(entries, stored)
+	}
+	return sortEntries(entries)
+}
+
+func (r *redisCache) Delete(city string) {
+	r.client.Del(r.namespace + city)
+}
+
+func (r *redisCache) Purge() {
+	keys, err := r.client.Scan(r.namespace + "*")
+	if err != nil {
+		return
+	}
+	r.client.Del(keys...)
+}
+
+// Stats only knows how many entries are held, the server is responsible for
+// memory and expiry
+func (r *redisCache) Stats() Stats {
+	keys, err := r.client.Scan(r.namespace + "*")
+	if err != nil {
+		return Stats{}
+	}
+	return Stats{Entries: len(keys)}
+}
+
+func (r *redisCache) Close() {
+	r.client.Close()
+}
+
+func (r *redisCache) get(city string) (Entry, error) {
+	value, err := r.client.Get(r.namespace + city)
+	if err != nil {
+		return Entry{}, errors.New(ErrorCacheMiss)
+	}
+
+	stored := Entry{}
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return Entry{}, errors.New(ErrorCacheMiss)
+	}
+	return stored, nil
+}
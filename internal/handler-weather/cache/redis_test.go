@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"errors"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	redisClient "github.com/jddcode/tech-test-ennismore/internal/redis-client"
+	redisTest "github.com/jddcode/tech-test-ennismore/internal/redis-client/redis-test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Redis city cache", func() {
+	var (
+		server      *redisTest.Server
+		myCache     Cache
+		predictions []structs.ResultForecast
+	)
+
+	BeforeEach(func() {
+		var err error
+		server, err = redisTest.NewServer()
+		Expect(err).ToNot(HaveOccurred())
+
+		myCache = NewRedis(Config{TTL: time.Hour, StaleWindow: time.Minute}, redisClient.New(redisClient.Config{Addr: server.Addr()}), "test:")
+		pointInTime, _ := time.Parse("2006-01-02 15:04:05", "2022-01-01 15:00:00")
+		predictions = []structs.ResultForecast{
+			structs.ResultForecast{
+				Start:      pointInTime,
+				End:        pointInTime,
+				Prediction: "warm and sunny",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		myCache.Close()
+		server.Close()
+	})
+
+	Context("Reading and writing entries", func() {
+		When("a city is stored", func() {
+			It("should be returned by another replica using the same namespace", func() {
+				myCache.Store("testcity", predictions, time.Time{})
+
+				replica := NewRedis(Config{TTL: time.Hour}, redisClient.New(redisClient.Config{Addr: server.Addr()}), "test:")
+				defer replica.Close()
+
+				data, err := replica.Get("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+			})
+
+			It("should not be visible in a different namespace", func() {
+				myCache.Store("testcity", predictions, time.Time{})
+
+				other := NewRedis(Config{TTL: time.Hour}, redisClient.New(redisClient.Config{Addr: server.Addr()}), "other:")
+				defer other.Close()
+
+				_, err := other.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+			})
+
+			It("should be given a TTL covering the expiry and the stale window", func() {
+				myCache.Store("testcity", predictions, time.Now().Add(time.Minute*10))
+				Expect(server.TTL("test:testcity")).To(BeNumerically("~", time.Minute*11, time.Second))
+			})
+		})
+
+		When("a city has expired but is within the stale window", func() {
+			It("should be a miss for fresh data and a hit for stale data", func() {
+				myCache.Store("testcity", predictions, time.Now().Add(time.Millisecond*10))
+				time.Sleep(time.Millisecond * 20)
+
+				_, err := myCache.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+
+				data, err := myCache.GetStale("testcity")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(predictions))
+			})
+		})
+
+		When("a city is stored already beyond the stale window", func() {
+			It("should remove it rather than keep it for ever", func() {
+				myCache.Store("testcity", predictions, time.Time{})
+				myCache.Store("testcity", predictions, time.Now().Add(-time.Minute*2))
+
+				_, err := myCache.GetStale("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				Expect(myCache.Stats().Entries).To(Equal(0))
+			})
+		})
+
+		When("the server cannot be reached", func() {
+			It("should treat it as a cache miss", func() {
+				client := redisClient.New(redisClient.Config{Addr: server.Addr()})
+				unreachable := NewRedis(Config{TTL: time.Hour}, client, "test:")
+				defer unreachable.Close()
+
+				unreachable.Store("testcity", predictions, time.Time{})
+				_, err := unreachable.Get("testcity")
+				Expect(err).ToNot(HaveOccurred())
+
+				server.Close()
+
+				_, err = unreachable.Get("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+				Expect(server.TTL("test:testcity")).To(BeNumerically(">", 0))
+
+				_, err = client.Get("test:testcity")
+				Expect(err).To(MatchError(HavePrefix("Could not connect to redis")))
+			})
+		})
+	})
+
+	Context("Reading statistics", func() {
+		When("several cities are stored", func() {
+			It("should count the entries in its namespace", func() {
+				myCache.Store("first", predictions, time.Time{})
+				myCache.Store("second", predictions, time.Time{})
+
+				Expect(myCache.Stats().Entries).To(Equal(2))
+			})
+		})
+	})
+
+	Context("Administering entries", func() {
+		When("the cities are listed", func() {
+			It("should return every entry in its namespace", func() {
+				myCache.Store("second", predictions, time.Time{})
+				myCache.Store("first", predictions, time.Time{})
+
+				entries := myCache.List()
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[0].City).To(Equal("first"))
+				Expect(entries[1].City).To(Equal("second"))
+			})
+		})
+
+		When("a city is deleted", func() {
+			It("should no longer be cached", func() {
+				myCache.Store("testcity", predictions, time.Time{})
+				myCache.Delete("testcity")
+
+				_, err := myCache.Lookup("testcity")
+				Expect(err).To(Equal(errors.New(ErrorCacheMiss)))
+			})
+		})
+
+		When("the cache is purged", func() {
+			It("should only remove keys in its namespace", func() {
+				other := NewRedis(Config{TTL: time.Hour}, redisClient.New(redisClient.Config{Addr: server.Addr()}), "other:")
+				defer other.Close()
+
+				myCache.Store("testcity", predictions, time.Time{})
+				other.Store("testcity", predictions, time.Time{})
+				myCache.Purge()
+
+				Expect(myCache.Stats().Entries).To(Equal(0))
+				Expect(other.Stats().Entries).To(Equal(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package handlerWeather
+
+import (
+	"context"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	"sync"
+)
+
+type flight struct {
+	done        chan struct{}
+	callers     int
+	cancel      context.CancelFunc
+	predictions []structs.ResultForecast
+	err         error
+}
+
+// flightGroup makes sure only one lookup per city is in progress at a time.
+// Callers asking for a city which is already being looked up wait for that
+// lookup to finish and share its result or error. The lookup is not tied to
+// the context of whichever caller started it: each caller stops waiting when
+// its own context is done, and the lookup is only cancelled once every caller
+// has given up on it.
+type flightGroup struct {
+	flights map[string]*flight
+	lock    sync.Mutex
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{
+		flights: make(map[string]*flight),
+	}
+}
+
+func (g *flightGroup) Do(ctx context.Context, city string, lookup func(ctx context.Context) ([]structs.ResultForecast, error)) ([]structs.ResultForecast, error) {
+	g.lock.Lock()
+	if existing, exists := g.flights[city]; exists {
+		existing.callers++
+		g.lock.Unlock()
+		return g.wait(ctx, city, existing)
+	}
+
+	lookupCtx, cancel := context.WithCancel(context.Background())
+	current := &flight{
+		done:    make(chan struct{}),
+		callers: 1,
+		cancel:  cancel,
+	}
+	g.flights[city] = current
+	g.lock.Unlock()
+
+	go func() {
+		current.predictions, current.err = lookup(lookupCtx)
+
+		g.lock.Lock()
+		if g.flights[city] == current {
+			delete(g.flights, city)
+		}
+		g.lock.Unlock()
+
+		cancel()
+		close(current.done)
+	}()
+	return g.wait(ctx, city, current)
+}
+
+func (g *flightGroup) wait(ctx context.Context, city string, current *flight) ([]structs.ResultForecast, error) {
+	select {
+	case <-current.done:
+		return current.predictions, current.err
+	case <-ctx.Done():
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	current.callers--
+	if current.callers < 1 {
+		current.cancel()
+		if g.flights[city] == current {
+			delete(g.flights, city)
+		}
+	}
+	return nil, ctx.Err()
+}
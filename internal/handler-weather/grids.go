@@ -0,0 +1,76 @@
+package handlerWeather
+
+import (
+	"container/list"
+	coreStructs "github.com/jddcode/tech-test-ennismore/internal/structs"
+	"sync"
+	"time"
+)
+
+type gridEntry struct {
+	key     string
+	grid    coreStructs.Grid
+	expires time.Time
+}
+
+// gridCache remembers the grid cell for each place for up to a TTL, so a
+// place is eventually looked up again should the NWS move it to another
+// cell. It holds at most maxEntries places, evicting the least recently used
+// first, so a stream of made up names cannot grow it without limit.
+type gridCache struct {
+	entries    map[string]*list.Element
+	order      *list.List
+	maxEntries int
+	ttl        time.Duration
+	lock       sync.Mutex
+}
+
+func newGridCache(maxEntries int, ttl time.Duration) *gridCache {
+	return &gridCache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+	}
+}
+
+func (g *gridCache) Load(key string) (coreStructs.Grid, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	element, exists := g.entries[key]
+	if !exists {
+		return coreStructs.Grid{}, false
+	}
+
+	entry := element.Value.(*gridEntry)
+	if !time.Now().Before(entry.expires) {
+		g.order.Remove(element)
+		delete(g.entries, key)
+		return coreStructs.Grid{}, false
+	}
+
+	g.order.MoveToFront(element)
+	return entry.grid, true
+}
+
+func (g *gridCache) Store(key string, grid coreStructs.Grid) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if existing, exists := g.entries[key]; exists {
+		g.order.Remove(existing)
+	}
+
+	g.entries[key] = g.order.PushFront(&gridEntry{
+		key:     key,
+		grid:    grid,
+		expires: time.Now().Add(g.ttl),
+	})
+
+	for g.order.Len() > g.maxEntries {
+		oldest := g.order.Back()
+		g.order.Remove(oldest)
+		delete(g.entries, oldest.Value.(*gridEntry).key)
+	}
+}
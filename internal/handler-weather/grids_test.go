@@ -0,0 +1,64 @@
+package handlerWeather
+
+import (
+	coreStructs "github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strconv"
+	"time"
+)
+
+var _ = Describe("Grid cell cache", func() {
+	var (
+		chicago coreStructs.Grid
+		boston  coreStructs.Grid
+	)
+
+	BeforeEach(func() {
+		chicago = coreStructs.Grid{ID: "LOT", X: 76, Y: 73}
+		boston = coreStructs.Grid{ID: "BOX", X: 71, Y: 90}
+	})
+
+	When("a place has been stored", func() {
+		It("should be loaded until its TTL has passed", func() {
+			grids := newGridCache(10, time.Millisecond*20)
+			grids.Store("chicago,us", chicago)
+
+			grid, known := grids.Load("chicago,us")
+			Expect(known).To(BeTrue())
+			Expect(grid).To(Equal(chicago))
+
+			time.Sleep(time.Millisecond * 30)
+			_, known = grids.Load("chicago,us")
+			Expect(known).To(BeFalse())
+			Expect(grids.entries).To(BeEmpty())
+		})
+	})
+
+	When("more places are stored than it may hold", func() {
+		It("should evict the least recently used", func() {
+			grids := newGridCache(2, time.Hour)
+			grids.Store("chicago,us", chicago)
+			grids.Store("boston,us", boston)
+			grids.Load("chicago,us")
+			grids.Store("windy city,us", chicago)
+
+			_, known := grids.Load("boston,us")
+			Expect(known).To(BeFalse())
+			_, known = grids.Load("chicago,us")
+			Expect(known).To(BeTrue())
+			_, known = grids.Load("windy city,us")
+			Expect(known).To(BeTrue())
+		})
+
+		It("should never hold more than its limit", func() {
+			grids := newGridCache(100, time.Hour)
+			for i := 0; i < 1000; i++ {
+				grids.Store("random"+strconv.Itoa(i)+",us", chicago)
+			}
+
+			Expect(grids.entries).To(HaveLen(100))
+			Expect(grids.order.Len()).To(Equal(100))
+		})
+	})
+})
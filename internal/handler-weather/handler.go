@@ -1,86 +1,148 @@
 package handlerWeather
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	cityName "github.com/jddcode/tech-test-ennismore/internal/city-name"
 	coOrdinateFinder "github.com/jddcode/tech-test-ennismore/internal/co-ordinate-finder"
 	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+	"github.com/jddcode/tech-test-ennismore/internal/place"
+	coreStructs "github.com/jddcode/tech-test-ennismore/internal/structs"
 	weatherFetcher "github.com/jddcode/tech-test-ennismore/internal/weather-fetcher"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	ErrorNoCities      = "Please supply a comma delimited list of cities as the URL parameter 'city'"
+	ErrorNoCities      = "Please supply a comma delimited list of cities as the URL parameter 'city', of ZIP codes as 'zip' or co-ordinates as 'lat' and 'lon'"
 	ErrorNoCoordinates = "Could not find co-ordinates for city: %s"
 	ErrorNoForecast    = "Could not get a weather forecast for the city: %s"
 	ErrorMashallResult = "Could not marshall result into valid json: %s"
+
+	WarningStale = `110 - "Response is Stale"`
+
+	// forecastUpdateInterval is how often the NWS updates its gridded
+	// forecasts, so how long after its updateTime a forecast with no
+	// validTimes is kept for
+	forecastUpdateInterval = time.Hour
 )
 
 type Cache interface {
 	Get(city string) ([]structs.ResultForecast, error)
-	Store(city string, prediction []structs.ResultForecast)
+	GetStale(city string) ([]structs.ResultForecast, error)
+	Store(city string, prediction []structs.ResultForecast, expires time.Time)
 }
 
 type Handler interface {
 	Handle(w http.ResponseWriter, r *http.Request)
+	Warm(ctx context.Context, city string) error
+	Key(city string) string
 }
 
+// lookupError is shown to the guest as its message, while keeping the error
+// from the upstream service which caused it
+type lookupError struct {
+	message string
+	cause   error
+}
+
+func (e lookupError) Error() string {
+	return e.message
+}
+
+func (e lookupError) Unwrap() error {
+	return e.cause
+}
+
+// handler caches each forecast under the NWS grid cell it belongs to, so every
+// name for a place, and every place in the same cell, shares one forecast.
+// The forecast is also cached under the key of the normalized place it was
+// asked for by, so the place is found in a shared or persisted cache without
+// first looking up its grid cell. The grid cell for each place is remembered
+// in grids, by its key, once it has been looked up, for as long as grids
+// keeps it.
 type handler struct {
 	coOrdinates coOrdinateFinder.Finder
 	weather     weatherFetcher.WeatherFetcher
 	cache       Cache
+	names       cityName.Normalizer
+	grids       *gridCache
+	refreshing  *sync.Map
+	inFlight    *flightGroup
 }
 
 func (h handler) Handle(w http.ResponseWriter, r *http.Request) {
-	cities := strings.Split(r.URL.Query().Get("city"), ",")
-	if len(cities) < 1 || len(cities[0]) < 1 {
+	defaults, err := h.defaults(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	queries, err := h.queries(r.URL.Query(), defaults)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if len(queries) < 1 {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(ErrorNoCities))
 		return
 	}
 
 	output := structs.Result{}
-	for _, city := range cities {
-		if data, err := h.cache.Get(city); err == nil {
+	anyStale := false
+	for _, query := range queries {
+		query = h.normalize(query)
+		key := query.Key()
+		if data, err := h.cache.Get(key); err == nil {
 			output.Data = append(output.Data, structs.ResultCity{
-				City:        city,
+				City:        h.name(query),
 				Predictions: data,
 			})
 			continue
 		}
 
-		pos, err := h.coOrdinates.Find(city, "usa")
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(fmt.Sprintf(ErrorNoCoordinates, city)))
-			return
+		if data, err := h.cache.GetStale(key); err == nil {
+			h.refreshInBackground(key, query)
+			anyStale = true
+			output.Data = append(output.Data, structs.ResultCity{
+				City:        h.name(query),
+				Predictions: data,
+				Stale:       true,
+			})
+			continue
 		}
 
-		forecasts, err := h.weather.Fetch(pos)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte(fmt.Sprintf(ErrorNoForecast, city)))
+		predictions, err := h.lookup(r.Context(), query, false)
+		if r.Context().Err() != nil {
 			return
 		}
 
-		predictions := make([]structs.ResultForecast, 0)
-		for _, forecast := range forecasts {
-			if forecast.Start.After(time.Now().Add(time.Hour * 48)) {
-				break
-			}
+		ambiguous := &coOrdinateFinder.AmbiguousError{}
+		if errors.As(err, &ambiguous) {
+			h.candidates(w, query.Text, ambiguous)
+			return
+		}
 
-			predictions = append(predictions, structs.ResultForecast{
-				Start:      forecast.Start,
-				End:        forecast.End,
-				Prediction: forecast.GetForecast(),
-			})
+		if err != nil {
+			w.WriteHeader(h.status(err))
+			w.Write([]byte(err.Error()))
+			return
 		}
 
-		h.cache.Store(city, predictions)
 		output.Data = append(output.Data, structs.ResultCity{
-			City:        city,
+			City:        h.name(query),
 			Predictions: predictions,
 		})
 	}
@@ -92,5 +154,297 @@ func (h handler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if anyStale {
+		w.Header().Set("Warning", WarningStale)
+	}
 	w.Write(bytes)
 }
+
+// candidates answers with the places an ambiguous city could have meant, each
+// with the URL parameters which ask for it alone: its city, state and country
+// where no other candidate shares them, otherwise its co-ordinates
+func (h handler) candidates(w http.ResponseWriter, city string, ambiguous *coOrdinateFinder.AmbiguousError) {
+	output := structs.ResultCandidates{
+		City:    city,
+		Message: ambiguous.Error(),
+	}
+
+	places := make(map[coreStructs.Place]int)
+	for _, candidate := range ambiguous.Candidates {
+		places[candidate.Place]++
+	}
+
+	for _, candidate := range ambiguous.Candidates {
+		refine := url.Values{
+			"lat": {strconv.FormatFloat(candidate.Position.Latitude, 'f', -1, 64)},
+			"lon": {strconv.FormatFloat(candidate.Position.Longitude, 'f', -1, 64)},
+		}
+		if places[candidate.Place] == 1 {
+			refine = url.Values{"city": {strings.Join([]string{candidate.Place.City, candidate.Place.State, strings.ToUpper(candidate.Place.Country)}, "|")}}
+		}
+
+		bounds := candidate.BoundingBox
+		output.Candidates = append(output.Candidates, structs.ResultCandidate{
+			Name:        candidate.Name,
+			Class:       candidate.Class,
+			Type:        candidate.Type,
+			Importance:  candidate.Importance,
+			Latitude:    candidate.Position.Latitude,
+			Longitude:   candidate.Position.Longitude,
+			BoundingBox: [4]float64{bounds.South, bounds.North, bounds.West, bounds.East},
+			Refine:      refine.Encode(),
+		})
+	}
+
+	// The refine values are query strings, so their ampersands are left as
+	// they are rather than escaped for HTML
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(output); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(fmt.Sprintf(ErrorMashallResult, err.Error())))
+		return
+	}
+
+	w.WriteHeader(http.StatusMultipleChoices)
+	w.Write(bytes.TrimSuffix(body.Bytes(), []byte("\n")))
+}
+
+// Warm fetches a fresh forecast for the city, which may give its state and
+// country as in the city parameter, and stores it in the cache regardless of
+// whether it is already cached
+func (h handler) Warm(ctx context.Context, city string) error {
+	query, err := h.parse(city)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.lookup(ctx, query, true)
+	return err
+}
+
+// Key returns the key the city's forecast is cached under: the key of its
+// normalized place, or the grid cell itself if it is given one, eg. "lot/76,73"
+func (h handler) Key(city string) string {
+	if strings.Contains(city, "/") {
+		return strings.ToLower(strings.TrimSpace(city))
+	}
+
+	query, err := h.parse(city)
+	if err != nil {
+		return h.names.Normalize(city)
+	}
+	return query.Key()
+}
+
+// name is what the guest is told a forecast is for: the city as they gave
+// it, or for a ZIP code or co-ordinates the nearest place the NWS knows of
+// once the grid cell has been looked up
+func (h handler) name(query place.Query) string {
+	if len(query.Place.City) > 0 {
+		return query.Text
+	}
+
+	if grid, known := h.grids.Load(query.Key()); known && len(grid.Location) > 0 {
+		return grid.Location
+	}
+	return query.Text
+}
+
+// queries reads every place asked for by the request: the cities, then the
+// ZIP codes, then the co-ordinates
+func (h handler) queries(values url.Values, defaults coreStructs.Place) ([]place.Query, error) {
+	cities, err := place.ParseList(values.Get("city"), defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	zips, err := place.ParseZips(values.Get("zip"))
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := place.ParsePositions(values["lat"], values["lon"])
+	if err != nil {
+		return nil, err
+	}
+	return append(append(cities, zips...), positions...), nil
+}
+
+// defaults reads the country and state which apply to any city in the request
+// which does not give its own
+func (h handler) defaults(query url.Values) (coreStructs.Place, error) {
+	country := query.Get("country")
+	if len(country) < 1 {
+		country = place.DefaultCountry
+	}
+
+	var err error
+	defaults := coreStructs.Place{}
+	if defaults.Country, err = place.Country(country); err != nil {
+		return defaults, err
+	}
+
+	defaults.State, err = place.State(query.Get("state"), defaults.Country)
+	return defaults, err
+}
+
+// parse reads a single place given as text, in the US unless it says otherwise
+func (h handler) parse(city string) (place.Query, error) {
+	queries, err := place.ParseList(city, coreStructs.Place{Country: place.DefaultCountry})
+	if err != nil {
+		return place.Query{}, err
+	}
+
+	if len(queries) < 1 {
+		return place.Query{}, errors.New(ErrorNoCities)
+	}
+	return h.normalize(queries[0]), nil
+}
+
+// normalize reduces the different ways of writing the city to one form, so
+// each is cached under the same key
+func (h handler) normalize(query place.Query) place.Query {
+	query.Place.City = h.names.Normalize(query.Place.City)
+	return query
+}
+
+// lookup gets a forecast for the place, sharing the result with any other
+// requests for the same place which arrive while it is in progress. Unless
+// refresh is set a forecast already cached for the place's grid cell, by way
+// of another name, is used rather than fetching a new one.
+func (h handler) lookup(ctx context.Context, query place.Query, refresh bool) ([]structs.ResultForecast, error) {
+	return h.inFlight.Do(ctx, query.Key(), func(ctx context.Context) ([]structs.ResultForecast, error) {
+		return h.fetch(ctx, query, refresh)
+	})
+}
+
+// fetch gets a forecast for the place from the upstream services and stores
+// it in the cache, returning an error suitable for the caller to see
+func (h handler) fetch(ctx context.Context, query place.Query, refresh bool) ([]structs.ResultForecast, error) {
+	grid, err := h.locate(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if !refresh {
+		if data, err := h.cache.Get(grid.Key()); err == nil {
+			h.cache.Store(query.Key(), data, h.until(data))
+			return data, nil
+		}
+	}
+
+	forecasts, err := h.weather.Forecast(ctx, grid)
+	if err != nil {
+		return nil, lookupError{fmt.Sprintf(ErrorNoForecast, query), err}
+	}
+
+	predictions := make([]structs.ResultForecast, 0)
+	for _, forecast := range forecasts {
+		if forecast.Start.After(time.Now().Add(time.Hour * 48)) {
+			break
+		}
+
+		predictions = append(predictions, structs.ResultForecast{
+			Start:      forecast.Start,
+			End:        forecast.End,
+			Prediction: forecast.GetForecast(),
+		})
+	}
+
+	expires := h.expiry(forecasts)
+	h.cache.Store(grid.Key(), predictions, expires)
+	h.cache.Store(query.Key(), predictions, expires)
+	return predictions, nil
+}
+
+// locate finds the grid cell for the place, remembering it for next time.
+// Co-ordinates given by the guest are used as they are, without geocoding.
+func (h handler) locate(ctx context.Context, query place.Query) (coreStructs.Grid, error) {
+	if grid, known := h.grids.Load(query.Key()); known {
+		return grid, nil
+	}
+
+	var pos coreStructs.CoOrdinates
+	if query.Position != nil {
+		pos = *query.Position
+	} else {
+		var err error
+		if pos, err = h.coOrdinates.Find(ctx, query.Place); err != nil {
+			return coreStructs.Grid{}, lookupError{fmt.Sprintf(ErrorNoCoordinates, query), err}
+		}
+	}
+
+	grid, err := h.weather.Locate(ctx, pos)
+	if err != nil {
+		return coreStructs.Grid{}, lookupError{fmt.Sprintf(ErrorNoForecast, query), err}
+	}
+
+	h.grids.Store(query.Key(), grid)
+	return grid, nil
+}
+
+// refreshInBackground starts a lookup for a place being served stale, unless
+// one is already running for its cache key. The lookup outlives the request
+// which started it. If it fails the stale entry is left in place to be served
+// until it falls out of the stale window.
+func (h handler) refreshInBackground(key string, query place.Query) {
+	if _, running := h.refreshing.LoadOrStore(key, true); running {
+		return
+	}
+
+	go func() {
+		defer h.refreshing.Delete(key)
+		h.lookup(context.Background(), query, true)
+	}()
+}
+
+// expiry works out when a forecast should stop being served from the cache:
+// whichever comes first of the end of the NWS validTimes interval and the end
+// of the first forecast period. A forecast with no validTimes is kept until
+// the NWS is next expected to update it, going by its updateTime. A zero time
+// leaves it to the cache's own TTL.
+func (h handler) expiry(forecasts []coreStructs.Weather) time.Time {
+	if len(forecasts) < 1 {
+		return time.Time{}
+	}
+
+	expires := forecasts[0].ValidUntil
+	if expires.IsZero() && !forecasts[0].Updated.IsZero() {
+		expires = forecasts[0].Updated.Add(forecastUpdateInterval)
+	}
+
+	if expires.IsZero() || forecasts[0].End.Before(expires) {
+		expires = forecasts[0].End
+	}
+
+	if !expires.After(time.Now()) {
+		return time.Time{}
+	}
+	return expires
+}
+
+// until works out when a forecast already cached for the grid cell should
+// expire once it is cached for another place too: the end of its first
+// period. A zero time leaves it to the cache's own TTL.
+func (h handler) until(predictions []structs.ResultForecast) time.Time {
+	if len(predictions) < 1 || !predictions[0].End.After(time.Now()) {
+		return time.Time{}
+	}
+	return predictions[0].End
+}
+
+// status picks the response code for a failed lookup. Failures of the
+// upstream services are reported as such rather than blamed on the request.
+func (h handler) status(err error) int {
+	contentType := &httpClient.ContentTypeError{}
+	connection := &url.Error{}
+	switch {
+	case httpClient.IsRateLimited(err), httpClient.IsCircuitOpen(err), httpClient.IsThrottled(err):
+		return http.StatusServiceUnavailable
+	case httpClient.IsServerError(err), httpClient.IsBodyTooLarge(err), errors.As(err, &contentType), errors.As(err, &connection):
+		return http.StatusBadGateway
+	}
+	return http.StatusBadRequest
+}
@@ -1,17 +1,27 @@
 package handlerWeather
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
+	cityName "github.com/jddcode/tech-test-ennismore/internal/city-name"
+	coOrdinateFinder "github.com/jddcode/tech-test-ennismore/internal/co-ordinate-finder"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/cache"
 	handlerStructs "github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
 	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	"github.com/jddcode/tech-test-ennismore/internal/place"
 	"github.com/jddcode/tech-test-ennismore/internal/structs"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,6 +38,7 @@ var _ = Describe("Weather forecast handler", func() {
 		mockWeatherFetcher *mocks.MockWeatherFetcher
 		mockCache          *mocks.MockCache
 		mockHandler        handler
+		testGrid           structs.Grid
 	)
 
 	BeforeEach(func() {
@@ -39,7 +50,12 @@ var _ = Describe("Weather forecast handler", func() {
 			coOrdinates: mockCoordinates,
 			weather:     mockWeatherFetcher,
 			cache:       mockCache,
+			names:       cityName.New(nil),
+			grids:       newGridCache(DefaultGridEntries, DefaultGridTTL),
+			refreshing:  &sync.Map{},
+			inFlight:    newFlightGroup(),
 		}
+		testGrid = structs.Grid{ID: "TST", X: 1, Y: 2, Forecast: "http://example.org"}
 	})
 
 	AfterEach(func() {
@@ -64,11 +80,12 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("a request is received with a city we cannot get co-ordinates for", func() {
 			It("should return an error", func() {
-				mockCache.EXPECT().Get("testcity").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
 				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
 				resp := httptest.NewRecorder()
 
-				mockCoordinates.EXPECT().Find("testcity", "usa").Return(structs.CoOrdinates{}, errors.New("could not find co-ordinates"))
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, errors.New("could not find co-ordinates"))
 				mockHandler.Handle(resp, mockReq)
 
 				result := resp.Result()
@@ -76,18 +93,202 @@ var _ = Describe("Weather forecast handler", func() {
 				data, err := ioutil.ReadAll(result.Body)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(string(data)).To(Equal(fmt.Sprintf(ErrorNoCoordinates, "testcity")))
+				Expect(string(data)).To(Equal(fmt.Sprintf(ErrorNoCoordinates, "testcity, US")))
+			})
+		})
+
+		When("a request is received with an unrecognised country", func() {
+			It("should return an error", func() {
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity&country=xx", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				result := resp.Result()
+				defer result.Body.Close()
+				data, err := ioutil.ReadAll(result.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(result.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(string(data)).To(Equal(fmt.Sprintf(place.ErrorCountry, "xx")))
+			})
+		})
+
+		When("a request is received with a country and state", func() {
+			It("should look for the city within them", func() {
+				mockCache.EXPECT().Get("springfield,illinois,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("springfield,illinois,us").Return(nil, errors.New("cache miss"))
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=Springfield&country=USA&state=IL", nil)
+				resp := httptest.NewRecorder()
+
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "springfield", State: "illinois", Country: "us"}).Return(structs.CoOrdinates{}, errors.New("could not find co-ordinates"))
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Body.String()).To(Equal(fmt.Sprintf(ErrorNoCoordinates, "springfield, illinois, US")))
+			})
+		})
+
+		When("a city is followed by its state as another entry", func() {
+			It("should reject the request and point to the bar separated form", func() {
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?"+url.Values{"city": {"Springfield, IL"}}.Encode(), nil)
+				resp := httptest.NewRecorder()
+
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Body.String()).To(Equal(fmt.Sprintf(place.ErrorBareCode, "IL", "Springfield", "IL")))
+			})
+		})
+
+		When("a city gives its own state and country", func() {
+			It("should look for the city within them", func() {
+				mockCache.EXPECT().Get("london,gb").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("london,gb").Return(nil, errors.New("cache miss"))
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?"+url.Values{"city": {"London|GB"}, "state": {"IL"}}.Encode(), nil)
+				resp := httptest.NewRecorder()
+
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "london", Country: "gb"}).Return(structs.CoOrdinates{}, errors.New("could not find co-ordinates"))
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Body.String()).To(Equal(fmt.Sprintf(ErrorNoCoordinates, "london, GB")))
+			})
+		})
+
+		When("a request is received with co-ordinates", func() {
+			It("should find the forecast without geocoding and name it after the nearest place", func() {
+				pos := structs.CoOrdinates{Latitude: 41.87, Longitude: -87.62}
+				testGrid.Location = "Chicago, IL"
+				mockCache.EXPECT().Get("41.87000,-87.62000").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("41.87000,-87.62000").Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), pos).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return([]handlerStructs.ResultForecast{{Prediction: "long dry spells"}}, nil)
+				mockCache.EXPECT().Store("41.87000,-87.62000", gomock.Any(), time.Time{})
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?lat=41.87&lon=-87.62", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(Equal(`{"forecast":[{"name":"Chicago, IL","detail":[{"starttime":"0001-01-01T00:00:00Z","endtime":"0001-01-01T00:00:00Z","description":"long dry spells"}]}]}`))
+			})
+		})
+
+		When("a request is received with co-ordinates out of range", func() {
+			It("should return an error", func() {
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?lat=141.87&lon=-87.62", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Body.String()).To(Equal(fmt.Sprintf(place.ErrorLatitude, "141.87")))
+			})
+		})
+
+		When("a request is received with a ZIP code", func() {
+			It("should geocode the ZIP code and name the forecast after the nearest place", func() {
+				zip := structs.Place{Postcode: "60601", Country: "us"}
+				testGrid.Location = "Chicago, IL"
+				mockCache.EXPECT().Get("60601,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("60601,us").Return(nil, errors.New("cache miss"))
+				mockCoordinates.EXPECT().Find(gomock.Any(), zip).Return(structs.CoOrdinates{}, nil)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return([]handlerStructs.ResultForecast{}, nil)
+				mockCache.EXPECT().Store("60601,us", gomock.Any(), time.Time{})
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?zip=60601-1234", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(Equal(`{"forecast":[{"name":"Chicago, IL","detail":[]}]}`))
+			})
+		})
+
+		When("a request is received with an invalid ZIP code", func() {
+			It("should return an error", func() {
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?zip=chicago", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Body.String()).To(Equal(fmt.Sprintf(place.ErrorZip, "chicago")))
+			})
+		})
+
+		When("a request is received with a city which could be several places", func() {
+			It("should list the places with how to ask for each", func() {
+				mockCache.EXPECT().Get("springfield,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("springfield,us").Return(nil, errors.New("cache miss"))
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=Springfield", nil)
+				resp := httptest.NewRecorder()
+
+				springfield := structs.Place{City: "springfield", Country: "us"}
+				mockCoordinates.EXPECT().Find(gomock.Any(), springfield).Return(structs.CoOrdinates{}, &coOrdinateFinder.AmbiguousError{
+					Place: springfield,
+					Candidates: []structs.Candidate{
+						{
+							Name:        "Springfield, Sangamon County, Illinois, United States",
+							Class:       "boundary",
+							Type:        "administrative",
+							Importance:  0.7,
+							BoundingBox: structs.BoundingBox{South: 39.7, North: 39.9, West: -89.8, East: -89.5},
+							Position:    structs.CoOrdinates{Latitude: 39.8, Longitude: -89.64},
+							Place:       structs.Place{City: "springfield", State: "illinois", Country: "us"},
+						},
+						{
+							Name:     "Springfield, Greene County, Missouri, United States",
+							Class:    "place",
+							Type:     "city",
+							Position: structs.CoOrdinates{Latitude: 37.2, Longitude: -93.29},
+							Place:    structs.Place{City: "springfield", Country: "us"},
+						},
+					},
+				})
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusMultipleChoices))
+				Expect(resp.Body.String()).To(Equal(`{"name":"Springfield","message":"springfield, US could be any of 2 places","candidates":[` +
+					`{"name":"Springfield, Sangamon County, Illinois, United States","class":"boundary","type":"administrative","importance":0.7,"latitude":39.8,"longitude":-89.64,"boundingbox":[39.7,39.9,-89.8,-89.5],"refine":"city=springfield%7Cillinois%7CUS"},` +
+					`{"name":"Springfield, Greene County, Missouri, United States","class":"place","type":"city","importance":0,"latitude":37.2,"longitude":-93.29,"boundingbox":[0,0,0,0],"refine":"city=springfield%7C%7CUS"}]}`))
+			})
+		})
+
+		When("a city could be several places in the same state", func() {
+			It("should ask for each by its co-ordinates", func() {
+				mockCache.EXPECT().Get("franklin,wisconsin,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("franklin,wisconsin,us").Return(nil, errors.New("cache miss"))
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=Franklin|WI", nil)
+				resp := httptest.NewRecorder()
+
+				franklin := structs.Place{City: "franklin", State: "wisconsin", Country: "us"}
+				mockCoordinates.EXPECT().Find(gomock.Any(), franklin).Return(structs.CoOrdinates{}, &coOrdinateFinder.AmbiguousError{
+					Place: franklin,
+					Candidates: []structs.Candidate{
+						{Name: "Franklin, Milwaukee County, Wisconsin, United States", Position: structs.CoOrdinates{Latitude: 42.89, Longitude: -88.04}, Place: franklin},
+						{Name: "Franklin, Sheboygan County, Wisconsin, United States", Position: structs.CoOrdinates{Latitude: 43.88, Longitude: -87.92}, Place: franklin},
+					},
+				})
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusMultipleChoices))
+				result := handlerStructs.ResultCandidates{}
+				Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+				Expect(result.Candidates).To(HaveLen(2))
+				Expect(result.Candidates[0].Refine).To(Equal("lat=42.89&lon=-88.04"))
+				Expect(result.Candidates[1].Refine).To(Equal("lat=43.88&lon=-87.92"))
 			})
 		})
 
 		When("a request is received we cannot get a forecast for", func() {
 			It("should return an error", func() {
-				mockCache.EXPECT().Get("testcity").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
 				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
 				resp := httptest.NewRecorder()
 
-				mockCoordinates.EXPECT().Find("testcity", "usa").Return(structs.CoOrdinates{}, nil)
-				mockWeatherFetcher.EXPECT().Fetch(structs.CoOrdinates{}).Return([]structs.Weather{structs.Weather{}}, errors.New("could not fetch forecast"))
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{structs.Weather{}}, errors.New("could not fetch forecast"))
 				mockHandler.Handle(resp, mockReq)
 
 				result := resp.Result()
@@ -95,17 +296,18 @@ var _ = Describe("Weather forecast handler", func() {
 				data, err := ioutil.ReadAll(result.Body)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(string(data)).To(Equal(fmt.Sprintf(ErrorNoForecast, "testcity")))
+				Expect(string(data)).To(Equal(fmt.Sprintf(ErrorNoForecast, "testcity, US")))
 			})
 		})
 
 		When("everything is working", func() {
 			It("should return a json weather forecast", func() {
-				mockCache.EXPECT().Get("testcity").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
 				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
 				resp := httptest.NewRecorder()
 
-				mockCoordinates.EXPECT().Find("testcity", "usa").Return(structs.CoOrdinates{}, nil)
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
 
 				setTime, _ := time.Parse("2006-01-02 15:04:05", "2020-01-01 12:00:00")
 				weatherResult := structs.Weather{
@@ -113,9 +315,12 @@ var _ = Describe("Weather forecast handler", func() {
 					End:   setTime,
 				}
 				weatherResult.Forecast.Long = "long dry spells"
-				mockWeatherFetcher.EXPECT().Fetch(structs.CoOrdinates{}).Return([]structs.Weather{weatherResult}, nil)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil)
 
-				mockCache.EXPECT().Store("testcity", gomock.Any())
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), gomock.Any())
 
 				mockHandler.Handle(resp, mockReq)
 
@@ -130,13 +335,15 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("everything is working and there are multiple cities", func() {
 			It("should return a json weather forecast", func() {
-				mockCache.EXPECT().Get("testcity").Return(nil, errors.New("cache miss"))
-				mockCache.EXPECT().Get("testcity2").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().Get("testcity2,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity2,us").Return(nil, errors.New("cache miss"))
 				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity,testcity2", nil)
 				resp := httptest.NewRecorder()
 
-				mockCoordinates.EXPECT().Find("testcity", "usa").Return(structs.CoOrdinates{}, nil)
-				mockCoordinates.EXPECT().Find("testcity2", "usa").Return(structs.CoOrdinates{}, nil)
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity2", Country: "us"}).Return(structs.CoOrdinates{}, nil)
 
 				setTime, _ := time.Parse("2006-01-02 15:04:05", "2020-01-01 12:00:00")
 				weatherResult := structs.Weather{
@@ -144,10 +351,18 @@ var _ = Describe("Weather forecast handler", func() {
 					End:   setTime,
 				}
 				weatherResult.Forecast.Long = "long dry spells"
-				mockWeatherFetcher.EXPECT().Fetch(structs.CoOrdinates{}).Return([]structs.Weather{weatherResult}, nil).Times(2)
-
-				mockCache.EXPECT().Store("testcity", gomock.Any())
-				mockCache.EXPECT().Store("testcity2", gomock.Any())
+				otherGrid := structs.Grid{ID: "TST", X: 3, Y: 4, Forecast: "http://example.org/other"}
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(otherGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().Get(otherGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil)
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), otherGrid).Return([]structs.Weather{weatherResult}, nil)
+
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store(otherGrid.Key(), gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store("testcity2,us", gomock.Any(), gomock.Any())
 
 				mockHandler.Handle(resp, mockReq)
 
@@ -162,11 +377,12 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("everything is working", func() {
 			It("should return a json weather forecast", func() {
-				mockCache.EXPECT().Get("testcity").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
 				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
 				resp := httptest.NewRecorder()
 
-				mockCoordinates.EXPECT().Find("testcity", "usa").Return(structs.CoOrdinates{}, nil)
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
 
 				setTime, _ := time.Parse("2006-01-02 15:04:05", "2020-01-01 12:00:00")
 				weatherResult := structs.Weather{
@@ -174,9 +390,12 @@ var _ = Describe("Weather forecast handler", func() {
 					End:   setTime,
 				}
 				weatherResult.Forecast.Long = "long dry spells"
-				mockWeatherFetcher.EXPECT().Fetch(structs.CoOrdinates{}).Return([]structs.Weather{weatherResult}, nil)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil)
 
-				mockCache.EXPECT().Store("testcity", gomock.Any())
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), gomock.Any())
 
 				mockHandler.Handle(resp, mockReq)
 
@@ -192,7 +411,7 @@ var _ = Describe("Weather forecast handler", func() {
 		When("there is a cache hit", func() {
 			It("should return from the cache and skip everything else", func() {
 				pointInTime, _ := time.Parse("2006-01-02 15:04:05", "2022-01-01 15:00:00")
-				mockCache.EXPECT().Get("testcity").Return([]handlerStructs.ResultForecast{
+				mockCache.EXPECT().Get("testcity,us").Return([]handlerStructs.ResultForecast{
 					handlerStructs.ResultForecast{
 						Start:      pointInTime,
 						End:        pointInTime,
@@ -213,5 +432,465 @@ var _ = Describe("Weather forecast handler", func() {
 				Expect(string(data)).To(Equal(`{"forecast":[{"name":"testcity","detail":[{"starttime":"2022-01-01T15:00:00Z","endtime":"2022-01-01T15:00:00Z","description":"warm and sunny"}]}]}`))
 			})
 		})
+
+		When("the forecast carries NWS validity information", func() {
+			It("should cache it until the earliest of the first period ending and the forecast validity ending", func() {
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+
+				periodEnd := time.Now().Add(time.Hour * 6).Truncate(time.Second)
+				weatherResult := structs.Weather{
+					Start:      time.Now(),
+					End:        periodEnd,
+					ValidUntil: time.Now().Add(time.Hour * 12),
+				}
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil)
+
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), periodEnd)
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), periodEnd)
+
+				mockHandler.Handle(resp, mockReq)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		When("the forecast carries an update time but no validity", func() {
+			It("should cache it until the NWS is next expected to update it", func() {
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+
+				updated := time.Now().Add(-time.Minute * 20).Truncate(time.Second)
+				weatherResult := structs.Weather{
+					Start:   time.Now(),
+					End:     time.Now().Add(time.Hour * 6),
+					Updated: updated,
+				}
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil)
+
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), updated.Add(time.Hour))
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), updated.Add(time.Hour))
+
+				mockHandler.Handle(resp, mockReq)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		When("the forecast validity is given in a zone other than UTC", func() {
+			It("should cache it until the same instant", func() {
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+
+				central := time.FixedZone("CST", -6*60*60)
+				validUntil := time.Now().Add(time.Hour * 2).Truncate(time.Second).In(central)
+				weatherResult := structs.Weather{
+					Start:      time.Now().UTC(),
+					End:        time.Now().Add(time.Hour * 6).UTC(),
+					ValidUntil: validUntil,
+				}
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil)
+
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), validUntil)
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), validUntil)
+
+				mockHandler.Handle(resp, mockReq)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		When("there is a stale cache hit", func() {
+			It("should return the stale data with a marker and refresh it in the background", func() {
+				pointInTime, _ := time.Parse("2006-01-02 15:04:05", "2022-01-01 15:00:00")
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return([]handlerStructs.ResultForecast{
+					handlerStructs.ResultForecast{
+						Start:      pointInTime,
+						End:        pointInTime,
+						Prediction: "warm and sunny",
+					},
+				}, nil)
+
+				refreshed := make(chan struct{})
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{structs.Weather{}}, nil)
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), gomock.Any()).Do(func(string, []handlerStructs.ResultForecast, time.Time) {
+					close(refreshed)
+				})
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+
+				mockHandler.Handle(resp, mockReq)
+
+				result := resp.Result()
+				defer result.Body.Close()
+				data, err := ioutil.ReadAll(result.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(string(data)).To(Equal(`{"forecast":[{"name":"testcity","detail":[{"starttime":"2022-01-01T15:00:00Z","endtime":"2022-01-01T15:00:00Z","description":"warm and sunny"}],"stale":true}]}`))
+				Expect(result.Header.Get("Warning")).To(Equal(WarningStale))
+				Eventually(refreshed).Should(BeClosed())
+			})
+		})
+
+		When("there is a stale cache hit and the background refresh fails", func() {
+			It("should leave the stale data in the cache", func() {
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return([]handlerStructs.ResultForecast{}, nil)
+
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, errors.New("could not find co-ordinates"))
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+
+				mockHandler.Handle(resp, mockReq)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				Eventually(func() bool {
+					_, running := mockHandler.refreshing.Load("testcity,us")
+					return running
+				}).Should(BeFalse())
+			})
+		})
+
+		When("a background refresh is already running for a stale city", func() {
+			It("should not start another one", func() {
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return([]handlerStructs.ResultForecast{}, nil)
+				mockHandler.refreshing.Store("testcity,us", true)
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+
+				mockHandler.Handle(resp, mockReq)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("Reporting upstream failures", func() {
+		BeforeEach(func() {
+			mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+			mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
+			mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+			mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+			mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+		})
+
+		DescribeTable("choosing the response code",
+			func(upstream error, expected int) {
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return(nil, fmt.Errorf("Error fetching forecast via GET: %w", upstream))
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(expected))
+				Expect(resp.Body.String()).To(Equal(fmt.Sprintf(ErrorNoForecast, "testcity, US")))
+			},
+			Entry("a server error", &httpClient.StatusError{Code: http.StatusServiceUnavailable}, http.StatusBadGateway),
+			Entry("an unexpected content type", &httpClient.ContentTypeError{ContentType: "text/html"}, http.StatusBadGateway),
+			Entry("a body which is too large", &httpClient.BodyTooLargeError{URL: "http://example.org", Limit: 1024}, http.StatusBadGateway),
+			Entry("a connection error", &url.Error{Op: "Get", URL: "http://example.org", Err: errors.New("connection refused")}, http.StatusBadGateway),
+			Entry("rate limiting", &httpClient.StatusError{Code: http.StatusTooManyRequests}, http.StatusServiceUnavailable),
+			Entry("an open circuit breaker", &httpClient.CircuitOpenError{Host: "api.weather.gov"}, http.StatusServiceUnavailable),
+			Entry("our own rate limit", &httpClient.RateLimitError{Host: "api.weather.gov", Wait: time.Second}, http.StatusServiceUnavailable),
+			Entry("a location with no forecast", &httpClient.StatusError{Code: http.StatusNotFound}, http.StatusBadRequest),
+		)
+	})
+
+	Context("Sharing forecasts between equivalent cities", func() {
+		var (
+			cached []handlerStructs.ResultForecast
+		)
+
+		BeforeEach(func() {
+			pointInTime, _ := time.Parse("2006-01-02 15:04:05", "2022-01-01 15:00:00")
+			cached = []handlerStructs.ResultForecast{
+				handlerStructs.ResultForecast{
+					Start:      pointInTime,
+					End:        pointInTime,
+					Prediction: "warm and sunny",
+				},
+			}
+		})
+
+		When("a city is requested with different case and spacing", func() {
+			It("should use the forecast cached for the normalized name", func() {
+				mockCache.EXPECT().Get("chicago,us").Return(cached, nil)
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=%20ChiCAGO%20", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring(`"description":"warm and sunny"`))
+			})
+		})
+
+		When("a new name resolves to a grid cell which is already cached", func() {
+			It("should share the cached forecast rather than fetching another", func() {
+				mockCache.EXPECT().Get("evanston,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("evanston,us").Return(nil, errors.New("cache miss"))
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "evanston", Country: "us"}).Return(structs.CoOrdinates{}, nil)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(cached, nil)
+				mockCache.EXPECT().Store("evanston,us", cached, time.Time{})
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=Evanston", nil)
+				resp := httptest.NewRecorder()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring(`"description":"warm and sunny"`))
+			})
+		})
+
+		When("a city is warmed", func() {
+			It("should fetch a new forecast even if its grid cell is cached", func() {
+				mockHandler.grids.Store("chicago,us", testGrid)
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{structs.Weather{}}, nil)
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store("chicago,us", gomock.Any(), gomock.Any())
+
+				Expect(mockHandler.Warm(context.Background(), "Chicago")).To(Succeed())
+			})
+		})
+
+		When("the key is requested for a city", func() {
+			It("should return its normalized name", func() {
+				Expect(mockHandler.Key(" New  York ")).To(Equal("new york,us"))
+			})
+		})
+
+		When("the key is requested for a grid cell", func() {
+			It("should return the grid cell", func() {
+				Expect(mockHandler.Key("LOT/76,73")).To(Equal("lot/76,73"))
+			})
+		})
+	})
+
+	Context("Handlers sharing a cache", func() {
+		When("a fresh handler is asked for a city another has already cached", func() {
+			It("should find the forecast without any upstream lookup", func() {
+				shared := cache.New(cache.Config{})
+				defer shared.Close()
+
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).Return(structs.CoOrdinates{}, nil).Times(1)
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil).Times(1)
+				weatherResult := structs.Weather{Start: time.Now(), End: time.Now().Add(time.Hour * 6)}
+				weatherResult.Forecast.Long = "long dry spells"
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil).Times(1)
+
+				first := mockHandler
+				first.cache = shared
+				second := first
+				second.grids = newGridCache(DefaultGridEntries, DefaultGridTTL)
+				second.inFlight = newFlightGroup()
+
+				mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+				first.Handle(resp, mockReq)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				mockReq, _ = http.NewRequest(http.MethodGet, "/weather?city=TestCity", nil)
+				resp = httptest.NewRecorder()
+				second.Handle(resp, mockReq)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring(`"description":"long dry spells"`))
+
+				entry, err := shared.Lookup(second.Key("TestCity"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entry.Predictions).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("Concurrent requests for a city which is not cached", func() {
+		const requests = 50
+
+		runConcurrently := func(release chan struct{}) []*httptest.ResponseRecorder {
+			responses := make([]*httptest.ResponseRecorder, requests)
+			var finished sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				finished.Add(1)
+				responses[i] = httptest.NewRecorder()
+				go func(resp *httptest.ResponseRecorder) {
+					defer GinkgoRecover()
+					defer finished.Done()
+					mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+					mockHandler.Handle(resp, mockReq)
+				}(responses[i])
+			}
+
+			Eventually(func() int {
+				return mockHandler.inFlight.callers("testcity,us")
+			}).Should(Equal(requests))
+
+			close(release)
+			finished.Wait()
+			return responses
+		}
+
+		BeforeEach(func() {
+			mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss")).Times(requests)
+			mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss")).Times(requests)
+		})
+
+		When("the lookup succeeds", func() {
+			It("should make one upstream lookup and share the result with every request", func() {
+				release := make(chan struct{})
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).DoAndReturn(func(context.Context, structs.Place) (structs.CoOrdinates, error) {
+					<-release
+					return structs.CoOrdinates{}, nil
+				}).Times(1)
+
+				setTime, _ := time.Parse("2006-01-02 15:04:05", "2020-01-01 12:00:00")
+				weatherResult := structs.Weather{
+					Start: setTime,
+					End:   setTime,
+				}
+				weatherResult.Forecast.Long = "long dry spells"
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil).Times(1)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss")).Times(1)
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{weatherResult}, nil).Times(1)
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), gomock.Any()).Times(1)
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), gomock.Any()).Times(1)
+
+				responses := runConcurrently(release)
+				for _, resp := range responses {
+					Expect(resp.Body.String()).To(Equal(`{"forecast":[{"name":"testcity","detail":[{"starttime":"2020-01-01T12:00:00Z","endtime":"2020-01-01T12:00:00Z","description":"long dry spells"}]}]}`))
+				}
+			})
+		})
+
+		When("the lookup fails", func() {
+			It("should make one upstream lookup and share the error with every request", func() {
+				release := make(chan struct{})
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).DoAndReturn(func(context.Context, structs.Place) (structs.CoOrdinates, error) {
+					<-release
+					return structs.CoOrdinates{}, errors.New("could not find co-ordinates")
+				}).Times(1)
+
+				responses := runConcurrently(release)
+				for _, resp := range responses {
+					Expect(resp.Code).To(Equal(http.StatusBadRequest))
+					Expect(resp.Body.String()).To(Equal(fmt.Sprintf(ErrorNoCoordinates, "testcity, US")))
+				}
+			})
+		})
+	})
+
+	Context("Requests which are cancelled", func() {
+		When("the only request for a city disconnects during the lookup", func() {
+			It("should cancel the upstream lookup and write nothing", func() {
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss"))
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss"))
+
+				started := make(chan struct{})
+				cancelled := make(chan struct{})
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).DoAndReturn(func(ctx context.Context, location structs.Place) (structs.CoOrdinates, error) {
+					close(started)
+					<-ctx.Done()
+					close(cancelled)
+					return structs.CoOrdinates{}, ctx.Err()
+				})
+
+				ctx, cancel := context.WithCancel(context.Background())
+				mockReq, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/weather?city=testcity", nil)
+				resp := httptest.NewRecorder()
+
+				go func() {
+					<-started
+					cancel()
+				}()
+				mockHandler.Handle(resp, mockReq)
+
+				Expect(resp.Body.Len()).To(Equal(0))
+				Eventually(cancelled).Should(BeClosed())
+			})
+		})
+
+		When("the request which started a lookup disconnects while another is waiting", func() {
+			It("should carry on with the lookup for the other request", func() {
+				mockCache.EXPECT().Get("testcity,us").Return(nil, errors.New("cache miss")).Times(2)
+				mockCache.EXPECT().GetStale("testcity,us").Return(nil, errors.New("cache miss")).Times(2)
+
+				release := make(chan struct{})
+				mockCoordinates.EXPECT().Find(gomock.Any(), structs.Place{City: "testcity", Country: "us"}).DoAndReturn(func(ctx context.Context, location structs.Place) (structs.CoOrdinates, error) {
+					<-release
+					return structs.CoOrdinates{}, ctx.Err()
+				})
+				mockWeatherFetcher.EXPECT().Locate(gomock.Any(), structs.CoOrdinates{}).Return(testGrid, nil)
+				mockCache.EXPECT().Get(testGrid.Key()).Return(nil, errors.New("cache miss"))
+				mockWeatherFetcher.EXPECT().Forecast(gomock.Any(), testGrid).Return([]structs.Weather{structs.Weather{}}, nil)
+				mockCache.EXPECT().Store(testGrid.Key(), gomock.Any(), gomock.Any())
+				mockCache.EXPECT().Store("testcity,us", gomock.Any(), gomock.Any())
+
+				ctx, cancel := context.WithCancel(context.Background())
+				first := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(first)
+					mockReq, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/weather?city=testcity", nil)
+					mockHandler.Handle(httptest.NewRecorder(), mockReq)
+				}()
+
+				second := httptest.NewRecorder()
+				finished := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(finished)
+					Eventually(func() bool {
+						mockHandler.inFlight.lock.Lock()
+						defer mockHandler.inFlight.lock.Unlock()
+						_, exists := mockHandler.inFlight.flights["testcity,us"]
+						return exists
+					}).Should(BeTrue())
+
+					mockReq, _ := http.NewRequest(http.MethodGet, "/weather?city=testcity", nil)
+					mockHandler.Handle(second, mockReq)
+				}()
+
+				Eventually(func() int {
+					return mockHandler.inFlight.callers("testcity,us")
+				}).Should(Equal(2))
+				cancel()
+				Eventually(first).Should(BeClosed())
+
+				close(release)
+				Eventually(finished).Should(BeClosed())
+				Expect(second.Code).To(Equal(http.StatusOK))
+			})
+		})
 	})
 })
+
+func (g *flightGroup) callers(city string) int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if existing, exists := g.flights[city]; exists {
+		return existing.callers
+	}
+	return 0
+}
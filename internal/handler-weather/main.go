@@ -1,14 +1,45 @@
 package handlerWeather
 
 import (
+	cityName "github.com/jddcode/tech-test-ennismore/internal/city-name"
 	coOrdinateFinder "github.com/jddcode/tech-test-ennismore/internal/co-ordinate-finder"
 	weatherFetcher "github.com/jddcode/tech-test-ennismore/internal/weather-fetcher"
+	"sync"
+	"time"
 )
 
-func New(cache Cache) Handler {
+const (
+	DefaultGridEntries = 10000
+	DefaultGridTTL     = time.Hour * 24
+)
+
+// Config bounds the grid cells remembered for places: at most GridEntries
+// places, each for GridTTL. Zero values use the defaults.
+type Config struct {
+	GridEntries int
+	GridTTL     time.Duration
+}
+
+func New(cache Cache, names cityName.Normalizer, coOrdinates coOrdinateFinder.Finder, weather weatherFetcher.WeatherFetcher, config Config) Handler {
+	config = config.withDefaults()
 	return handler{
-		coOrdinates: coOrdinateFinder.New(),
-		weather:     weatherFetcher.New(),
+		coOrdinates: coOrdinates,
+		weather:     weather,
 		cache:       cache,
+		names:       names,
+		grids:       newGridCache(config.GridEntries, config.GridTTL),
+		refreshing:  &sync.Map{},
+		inFlight:    newFlightGroup(),
+	}
+}
+
+func (c Config) withDefaults() Config {
+	if c.GridEntries < 1 {
+		c.GridEntries = DefaultGridEntries
+	}
+
+	if c.GridTTL <= 0 {
+		c.GridTTL = DefaultGridTTL
 	}
+	return c
 }
@@ -0,0 +1,21 @@
+package structs
+
+// ResultCandidates lists the places a city could have meant, for the guest to
+// choose from by repeating the request with the URL parameters in one of
+// their Refine values
+type ResultCandidates struct {
+	City       string            `json:"name"`
+	Message    string            `json:"message"`
+	Candidates []ResultCandidate `json:"candidates"`
+}
+
+type ResultCandidate struct {
+	Name        string     `json:"name"`
+	Class       string     `json:"class"`
+	Type        string     `json:"type"`
+	Importance  float64    `json:"importance"`
+	Latitude    float64    `json:"latitude"`
+	Longitude   float64    `json:"longitude"`
+	BoundingBox [4]float64 `json:"boundingbox"`
+	Refine      string     `json:"refine"`
+}
@@ -3,4 +3,5 @@ package structs
 type ResultCity struct {
 	City        string           `json:"name"`
 	Predictions []ResultForecast `json:"detail"`
+	Stale       bool             `json:"stale,omitempty"`
 }
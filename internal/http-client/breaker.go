@@ -0,0 +1,201 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ErrorCircuitOpen = "Requests to %s are suspended after repeated failures"
+
+	DefaultBreakerFailures = 5
+	DefaultBreakerCoolDown = time.Second * 30
+
+	StateClosed   BreakerState = "closed"
+	StateOpen     BreakerState = "open"
+	StateHalfOpen BreakerState = "half-open"
+)
+
+type BreakerState string
+
+type BreakerConfig struct {
+	Failures int
+	CoolDown time.Duration
+}
+
+// BreakerStatus describes the circuit for one host, along with counts of how
+// often it has opened and how many requests it has turned away
+type BreakerStatus struct {
+	Host     string       `json:"host"`
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
+	OpenedAt time.Time    `json:"openedAt"`
+	Opens    uint64       `json:"opens"`
+	Rejected uint64       `json:"rejected"`
+}
+
+// CircuitOpenError is returned without making a request while the circuit
+// for the host is open
+type CircuitOpenError struct {
+	Host       string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf(ErrorCircuitOpen, e.Host)
+}
+
+// IsCircuitOpen reports whether err was caused by an open circuit breaker
+func IsCircuitOpen(err error) bool {
+	open := &CircuitOpenError{}
+	return errors.As(err, &open)
+}
+
+type Breaker interface {
+	Client
+	States() []BreakerStatus
+}
+
+type circuit struct {
+	status BreakerStatus
+	trial  bool
+}
+
+// breaker keeps a circuit for each upstream host. A circuit opens after the
+// configured number of consecutive failures, the same failures which are
+// worth retrying, and while open every request to the host fails straight
+// away. Once the cool-down has passed the circuit is half-open and lets a
+// single trial request through, closing again if it succeeds and reopening
+// if it fails.
+type breaker struct {
+	client   Client
+	config   BreakerConfig
+	circuits map[string]*circuit
+	lock     sync.Mutex
+}
+
+func (b *breaker) Get(ctx context.Context, rawURL string) (string, error) {
+	var body string
+	err := b.guard(ctx, rawURL, func() (err error) {
+		body, err = b.client.Get(ctx, rawURL)
+		return err
+	})
+	return body, err
+}
+
+// Open only records the outcome up to the response headers, as an error
+// reading the body is up to the caller to notice
+func (b *breaker) Open(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := b.guard(ctx, rawURL, func() (err error) {
+		body, err = b.client.Open(ctx, rawURL)
+		return err
+	})
+	return body, err
+}
+
+func (b *breaker) guard(ctx context.Context, rawURL string, request func() error) error {
+	host := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		host = parsed.Host
+	}
+
+	trial, err := b.allow(host)
+	if err != nil {
+		return err
+	}
+
+	err = request()
+	b.record(host, trial, err, ctx.Err() != nil)
+	return err
+}
+
+func (b *breaker) States() []BreakerStatus {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	states := make([]BreakerStatus, 0, len(b.circuits))
+	for _, current := range b.circuits {
+		b.advance(current)
+		states = append(states, current.status)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Host < states[j].Host
+	})
+	return states
+}
+
+// allow decides whether a request to the host may go ahead, and whether it is
+// the trial request of a half-open circuit
+func (b *breaker) allow(host string) (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	current, exists := b.circuits[host]
+	if !exists {
+		current = &circuit{status: BreakerStatus{Host: host, State: StateClosed}}
+		b.circuits[host] = current
+	}
+
+	b.advance(current)
+	switch {
+	case current.status.State == StateOpen, current.status.State == StateHalfOpen && current.trial:
+		current.status.Rejected++
+		return false, &CircuitOpenError{
+			Host:       host,
+			RetryAfter: time.Until(current.status.OpenedAt.Add(b.config.CoolDown)),
+		}
+	case current.status.State == StateHalfOpen:
+		current.trial = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates the circuit with the outcome of a request. A request which
+// was abandoned by its caller, or held back by our own rate limit, says
+// nothing about the host, so it only ends a trial without deciding it.
+func (b *breaker) record(host string, trial bool, err error, abandoned bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	current := b.circuits[host]
+	if trial {
+		current.trial = false
+	}
+
+	switch {
+	case abandoned, IsThrottled(err):
+	case err == nil || !retryable(err):
+		current.status.State = StateClosed
+		current.status.Failures = 0
+	default:
+		current.status.Failures++
+		if trial || current.status.State == StateClosed && current.status.Failures >= b.config.Failures {
+			b.trip(current)
+		}
+	}
+}
+
+func (b *breaker) trip(current *circuit) {
+	if current.status.State != StateOpen {
+		current.status.Opens++
+	}
+	current.status.State = StateOpen
+	current.status.OpenedAt = time.Now()
+}
+
+// advance moves an open circuit to half-open once its cool-down has passed
+func (b *breaker) advance(current *circuit) {
+	if current.status.State == StateOpen && time.Since(current.status.OpenedAt) >= b.config.CoolDown {
+		current.status.State = StateHalfOpen
+		current.trial = false
+	}
+}
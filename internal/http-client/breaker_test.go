@@ -0,0 +1,222 @@
+package httpClient
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var _ = Describe("Circuit breaking HTTP client", func() {
+	var (
+		config   BreakerConfig
+		failing  map[string]bool
+		requests map[string]int
+		upstream clientFunc
+	)
+
+	BeforeEach(func() {
+		config = BreakerConfig{
+			Failures: 3,
+			CoolDown: time.Millisecond * 20,
+		}
+		failing = map[string]bool{"http://one.example/a": true}
+		requests = make(map[string]int)
+		upstream = func(ctx context.Context, url string) (string, error) {
+			requests[url]++
+			if failing[url] {
+				return "", &StatusError{Code: http.StatusBadGateway, URL: url}
+			}
+			return "some body", nil
+		}
+	})
+
+	Context("Opening the circuit", func() {
+		When("a host fails the configured number of times in a row", func() {
+			It("should fail further requests without making them", func() {
+				web := NewBreaker(upstream, config)
+				for i := 0; i < 3; i++ {
+					_, err := web.Get(context.Background(), "http://one.example/a")
+					Expect(IsServerError(err)).To(BeTrue())
+				}
+
+				_, err := web.Get(context.Background(), "http://one.example/b")
+				Expect(IsCircuitOpen(err)).To(BeTrue())
+				Expect(err.Error()).To(Equal("Requests to one.example are suspended after repeated failures"))
+				Expect(requests["http://one.example/b"]).To(Equal(0))
+			})
+
+			It("should keep the circuits for other hosts closed", func() {
+				web := NewBreaker(upstream, config)
+				for i := 0; i < 3; i++ {
+					web.Get(context.Background(), "http://one.example/a")
+				}
+
+				body, err := web.Get(context.Background(), "http://two.example/a")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(Equal("some body"))
+			})
+		})
+
+		When("a success comes between failures", func() {
+			It("should start counting again", func() {
+				web := NewBreaker(upstream, config)
+				web.Get(context.Background(), "http://one.example/a")
+				web.Get(context.Background(), "http://one.example/a")
+				web.Get(context.Background(), "http://one.example/ok")
+				web.Get(context.Background(), "http://one.example/a")
+
+				Expect(web.States()[0].State).To(Equal(StateClosed))
+				Expect(web.States()[0].Failures).To(Equal(1))
+			})
+		})
+
+		When("requests fail in a way which is not worth retrying", func() {
+			It("should not count them as failures", func() {
+				web := NewBreaker(clientFunc(func(ctx context.Context, url string) (string, error) {
+					return "", &StatusError{Code: http.StatusNotFound, URL: url}
+				}), config)
+				for i := 0; i < 5; i++ {
+					_, err := web.Get(context.Background(), "http://one.example/a")
+					Expect(IsNotFound(err)).To(BeTrue())
+				}
+
+				Expect(web.States()[0].State).To(Equal(StateClosed))
+			})
+		})
+
+		When("responses have the wrong content type", func() {
+			It("should not count them as failures", func() {
+				web := NewBreaker(clientFunc(func(ctx context.Context, url string) (string, error) {
+					return "", &ContentTypeError{ContentType: "text/html", URL: url}
+				}), config)
+				for i := 0; i < 5; i++ {
+					web.Get(context.Background(), "http://one.example/a")
+				}
+
+				Expect(web.States()[0].State).To(Equal(StateClosed))
+				Expect(web.States()[0].Failures).To(Equal(0))
+			})
+		})
+
+		When("requests are held back by our own rate limit", func() {
+			It("should not count them as failures", func() {
+				web := NewBreaker(clientFunc(func(ctx context.Context, url string) (string, error) {
+					return "", &RateLimitError{Host: "one.example", Wait: time.Second}
+				}), config)
+				for i := 0; i < 5; i++ {
+					web.Get(context.Background(), "http://one.example/a")
+				}
+
+				Expect(web.States()[0].State).To(Equal(StateClosed))
+				Expect(web.States()[0].Failures).To(Equal(0))
+			})
+		})
+
+		When("the caller abandons its requests", func() {
+			It("should not count them as failures", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				web := NewBreaker(clientFunc(func(ctx context.Context, url string) (string, error) {
+					return "", ctx.Err()
+				}), config)
+				for i := 0; i < 5; i++ {
+					web.Get(ctx, "http://one.example/a")
+				}
+
+				Expect(web.States()[0].State).To(Equal(StateClosed))
+			})
+		})
+	})
+
+	Context("Recovering after the cool-down", func() {
+		var (
+			web Breaker
+		)
+
+		BeforeEach(func() {
+			web = NewBreaker(upstream, config)
+			for i := 0; i < 3; i++ {
+				web.Get(context.Background(), "http://one.example/a")
+			}
+			time.Sleep(config.CoolDown)
+		})
+
+		When("the trial request succeeds", func() {
+			It("should close the circuit", func() {
+				Expect(web.States()[0].State).To(Equal(StateHalfOpen))
+				failing["http://one.example/a"] = false
+
+				_, err := web.Get(context.Background(), "http://one.example/a")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(web.States()[0].State).To(Equal(StateClosed))
+				Expect(web.States()[0].Failures).To(Equal(0))
+			})
+		})
+
+		When("the trial request fails", func() {
+			It("should open the circuit again", func() {
+				_, err := web.Get(context.Background(), "http://one.example/a")
+				Expect(IsServerError(err)).To(BeTrue())
+
+				_, err = web.Get(context.Background(), "http://one.example/a")
+				Expect(IsCircuitOpen(err)).To(BeTrue())
+				Expect(web.States()[0].Opens).To(Equal(uint64(2)))
+			})
+		})
+
+		When("a trial request is already in progress", func() {
+			It("should fail other requests straight away", func() {
+				release := make(chan struct{})
+				started := make(chan struct{})
+				web = NewBreaker(clientFunc(func(ctx context.Context, url string) (string, error) {
+					if url == "http://one.example/slow" {
+						close(started)
+						<-release
+						return "some body", nil
+					}
+					return "", syscall.ECONNREFUSED
+				}), config)
+				for i := 0; i < 3; i++ {
+					web.Get(context.Background(), "http://one.example/a")
+				}
+				time.Sleep(config.CoolDown)
+
+				done := make(chan error)
+				go func() {
+					_, err := web.Get(context.Background(), "http://one.example/slow")
+					done <- err
+				}()
+				<-started
+
+				_, err := web.Get(context.Background(), "http://one.example/a")
+				Expect(IsCircuitOpen(err)).To(BeTrue())
+
+				close(release)
+				Expect(<-done).ToNot(HaveOccurred())
+				Expect(web.States()[0].State).To(Equal(StateClosed))
+			})
+		})
+	})
+
+	Context("Reporting the state of each circuit", func() {
+		It("should list each host in order with its counts", func() {
+			web := NewBreaker(upstream, config)
+			web.Get(context.Background(), "http://two.example/a")
+			for i := 0; i < 4; i++ {
+				web.Get(context.Background(), "http://one.example/a")
+			}
+
+			states := web.States()
+			Expect(states).To(HaveLen(2))
+			Expect(states[0].Host).To(Equal("one.example"))
+			Expect(states[0].State).To(Equal(StateOpen))
+			Expect(states[0].Opens).To(Equal(uint64(1)))
+			Expect(states[0].Rejected).To(Equal(uint64(1)))
+			Expect(states[1].Host).To(Equal("two.example"))
+			Expect(states[1].State).To(Equal(StateClosed))
+		})
+	})
+})
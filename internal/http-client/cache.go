@@ -0,0 +1,227 @@
+package httpClient
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheEntries = 1000
+)
+
+type cachedResponse struct {
+	url     string
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// validated reports whether the response can be revalidated with the server
+func (c *cachedResponse) validated() bool {
+	return len(c.header.Get("ETag")) > 0 || len(c.header.Get("Last-Modified")) > 0
+}
+
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(c.status) + " " + http.StatusText(c.status),
+		StatusCode:    c.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// cachingTransport keeps successful GET responses by URL and follows their
+// Cache-Control: a response is reused without asking the server until its
+// max-age has passed, then revalidated with If-None-Match or
+// If-Modified-Since, a 304 reusing the stored body. Responses marked no-store
+// are never kept and those marked no-cache are revalidated every time. The
+// least recently used response is dropped once there are maxEntries, and a
+// body longer than maxBodySize is passed on without being kept.
+type cachingTransport struct {
+	transport   http.RoundTripper
+	maxEntries  int
+	maxBodySize int64
+	entries     map[string]*list.Element
+	order       *list.List
+	lock        sync.Mutex
+}
+
+func newCachingTransport(transport http.RoundTripper, maxEntries int, maxBodySize int64) *cachingTransport {
+	return &cachingTransport{
+		transport:   transport,
+		maxEntries:  maxEntries,
+		maxBodySize: maxBodySize,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.transport.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	cached := t.lookup(key)
+	if cached != nil && time.Now().Before(cached.expires) {
+		return cached.response(req), nil
+	}
+
+	if cached != nil && cached.validated() {
+		req = req.Clone(req.Context())
+		if etag := cached.header.Get("ETag"); len(etag) > 0 {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.header.Get("Last-Modified"); len(modified) > 0 {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return t.revalidated(cached, resp.Header).response(req), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || !storable(resp.Header) || resp.ContentLength > t.maxBodySize {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, t.maxBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if int64(len(body)) > t.maxBodySize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.store(&cachedResponse{
+		url:     key,
+		status:  resp.StatusCode,
+		header:  resp.Header.Clone(),
+		body:    body,
+		expires: freshUntil(resp.Header),
+	})
+	return resp, nil
+}
+
+func (t *cachingTransport) lookup(key string) *cachedResponse {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	element, exists := t.entries[key]
+	if !exists {
+		return nil
+	}
+	t.order.MoveToFront(element)
+	return element.Value.(*cachedResponse)
+}
+
+// revalidated replaces a stored response with a copy carrying the headers
+// from the server's 304, which may give it a new lifetime or validators
+func (t *cachingTransport) revalidated(cached *cachedResponse, header http.Header) *cachedResponse {
+	updated := *cached
+	updated.header = cached.header.Clone()
+	for name, values := range header {
+		updated.header[name] = values
+	}
+	updated.expires = freshUntil(updated.header)
+
+	t.store(&updated)
+	return &updated
+}
+
+func (t *cachingTransport) store(cached *cachedResponse) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if element, exists := t.entries[cached.url]; exists {
+		element.Value = cached
+		t.order.MoveToFront(element)
+		return
+	}
+
+	t.entries[cached.url] = t.order.PushFront(cached)
+	for t.order.Len() > t.maxEntries {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.entries, oldest.Value.(*cachedResponse).url)
+	}
+}
+
+// storable reports whether a response may be kept: it must not be marked
+// no-store, and must either have a lifetime or be possible to revalidate
+func storable(header http.Header) bool {
+	directives := cacheControl(header)
+	if _, noStore := directives["no-store"]; noStore {
+		return false
+	}
+	return freshUntil(header).After(time.Now()) || len(header.Get("ETag")) > 0 || len(header.Get("Last-Modified")) > 0
+}
+
+// freshUntil works out when a response must next be revalidated from its
+// max-age, less any Age it already had, or failing that its Expires header
+func freshUntil(header http.Header) time.Time {
+	now := time.Now()
+	directives := cacheControl(header)
+	if _, noCache := directives["no-cache"]; noCache {
+		return now
+	}
+
+	if maxAge, exists := directives["max-age"]; exists {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return now
+		}
+
+		age, _ := strconv.Atoi(header.Get("Age"))
+		return now.Add(time.Duration(seconds-age) * time.Second)
+	}
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return expires
+	}
+	return now
+}
+
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(directive), "=", 2)
+		if len(parts[0]) < 1 {
+			continue
+		}
+
+		value := ""
+		if len(parts) == 2 {
+			value = strings.Trim(parts[1], `"`)
+		}
+		directives[strings.ToLower(parts[0])] = value
+	}
+	return directives
+}
@@ -0,0 +1,210 @@
+package httpClient
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// validatingServer answers with the configured caching headers, and with a
+// 304 to any request whose validators match them
+type validatingServer struct {
+	server       *httptest.Server
+	cacheControl string
+	etag         string
+	lastModified string
+	body         string
+	requests     int
+	conditional  int
+	lock         sync.Mutex
+}
+
+func newValidatingServer(cacheControl, etag, lastModified string) *validatingServer {
+	validating := &validatingServer{
+		cacheControl: cacheControl,
+		etag:         etag,
+		lastModified: lastModified,
+		body:         "some body",
+	}
+
+	validating.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		validating.lock.Lock()
+		defer validating.lock.Unlock()
+
+		validating.requests++
+		if len(validating.cacheControl) > 0 {
+			w.Header().Set("Cache-Control", validating.cacheControl)
+		}
+		if len(validating.etag) > 0 {
+			w.Header().Set("ETag", validating.etag)
+		}
+		if len(validating.lastModified) > 0 {
+			w.Header().Set("Last-Modified", validating.lastModified)
+		}
+
+		matchesETag := len(validating.etag) > 0 && r.Header.Get("If-None-Match") == validating.etag
+		matchesModified := len(validating.lastModified) > 0 && r.Header.Get("If-Modified-Since") == validating.lastModified
+		if matchesETag || matchesModified {
+			validating.conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(validating.body))
+	}))
+	return validating
+}
+
+func (v *validatingServer) counts() (int, int) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.requests, v.conditional
+}
+
+var _ = Describe("HTTP caching", func() {
+	get := func(web Client, url string) string {
+		body, err := web.Get(context.Background(), url)
+		Expect(err).ToNot(HaveOccurred())
+		return body
+	}
+
+	When("a response has a max-age", func() {
+		It("should be reused without asking the server until it expires", func() {
+			validating := newValidatingServer("max-age=60", "", "")
+			defer validating.server.Close()
+			web := New(Config{CacheEntries: 10})
+
+			Expect(get(web, validating.server.URL)).To(Equal("some body"))
+			Expect(get(web, validating.server.URL)).To(Equal("some body"))
+
+			requests, _ := validating.counts()
+			Expect(requests).To(Equal(1))
+		})
+	})
+
+	When("a response with an ETag has expired", func() {
+		It("should be revalidated and reused when the server says it is unchanged", func() {
+			validating := newValidatingServer("max-age=0", `"abc"`, "")
+			defer validating.server.Close()
+			web := New(Config{CacheEntries: 10})
+
+			get(web, validating.server.URL)
+			Expect(get(web, validating.server.URL)).To(Equal("some body"))
+
+			requests, conditional := validating.counts()
+			Expect(requests).To(Equal(2))
+			Expect(conditional).To(Equal(1))
+		})
+
+		It("should use the new body when the server says it has changed", func() {
+			validating := newValidatingServer("max-age=0", `"abc"`, "")
+			defer validating.server.Close()
+			web := New(Config{CacheEntries: 10})
+
+			get(web, validating.server.URL)
+			validating.lock.Lock()
+			validating.etag = `"def"`
+			validating.body = "new body"
+			validating.lock.Unlock()
+
+			Expect(get(web, validating.server.URL)).To(Equal("new body"))
+		})
+	})
+
+	When("a response only has a Last-Modified date", func() {
+		It("should be revalidated with If-Modified-Since", func() {
+			validating := newValidatingServer("", "", time.Now().UTC().Format(http.TimeFormat))
+			defer validating.server.Close()
+			web := New(Config{CacheEntries: 10})
+
+			get(web, validating.server.URL)
+			Expect(get(web, validating.server.URL)).To(Equal("some body"))
+
+			_, conditional := validating.counts()
+			Expect(conditional).To(Equal(1))
+		})
+	})
+
+	When("the revalidation gives the response a new lifetime", func() {
+		It("should reuse it without asking the server again", func() {
+			validating := newValidatingServer("no-cache", `"abc"`, "")
+			defer validating.server.Close()
+			web := New(Config{CacheEntries: 10})
+
+			get(web, validating.server.URL)
+			validating.lock.Lock()
+			validating.cacheControl = "max-age=60"
+			validating.lock.Unlock()
+			get(web, validating.server.URL)
+			get(web, validating.server.URL)
+
+			requests, conditional := validating.counts()
+			Expect(requests).To(Equal(2))
+			Expect(conditional).To(Equal(1))
+		})
+	})
+
+	When("a response is marked no-store", func() {
+		It("should never be reused", func() {
+			validating := newValidatingServer("no-store, max-age=60", `"abc"`, "")
+			defer validating.server.Close()
+			web := New(Config{CacheEntries: 10})
+
+			get(web, validating.server.URL)
+			get(web, validating.server.URL)
+
+			requests, conditional := validating.counts()
+			Expect(requests).To(Equal(2))
+			Expect(conditional).To(Equal(0))
+		})
+	})
+
+	When("caching is turned off", func() {
+		It("should ask the server every time", func() {
+			validating := newValidatingServer("max-age=60", `"abc"`, "")
+			defer validating.server.Close()
+			web := New(Config{})
+
+			get(web, validating.server.URL)
+			get(web, validating.server.URL)
+
+			requests, conditional := validating.counts()
+			Expect(requests).To(Equal(2))
+			Expect(conditional).To(Equal(0))
+		})
+	})
+
+	When("more responses are cached than allowed", func() {
+		It("should drop the least recently used", func() {
+			validating := newValidatingServer("max-age=60", "", "")
+			defer validating.server.Close()
+			web := New(Config{CacheEntries: 2})
+
+			get(web, validating.server.URL+"/a")
+			get(web, validating.server.URL+"/b")
+			get(web, validating.server.URL+"/a")
+			get(web, validating.server.URL+"/c")
+			get(web, validating.server.URL+"/a")
+			get(web, validating.server.URL+"/b")
+
+			requests, _ := validating.counts()
+			Expect(requests).To(Equal(4))
+		})
+	})
+
+	Context("Working out how long a response is fresh", func() {
+		It("should take any Age from the max-age", func() {
+			header := http.Header{"Cache-Control": []string{"public, max-age=120"}, "Age": []string{"60"}}
+			Expect(time.Until(freshUntil(header))).To(BeNumerically("~", time.Minute, time.Second))
+		})
+
+		It("should fall back to the Expires header", func() {
+			expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+			header := http.Header{"Expires": []string{expires.Format(http.TimeFormat)}}
+			Expect(freshUntil(header)).To(BeTemporally("==", expires))
+		})
+	})
+})
@@ -1,26 +1,168 @@
 package httpClient
 
 import (
+	"context"
+	"crypto/tls"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Client makes GET requests to the upstream services. Open streams the body,
+// which the caller must close, while Get reads all of it.
+//
 //go:generate mockgen -destination=../mocks/mock-http-client.go -package=mocks . Client
 type Client interface {
-	Get(url string) (string, error)
+	Get(ctx context.Context, url string) (string, error)
+	Open(ctx context.Context, url string) (io.ReadCloser, error)
 }
 
-type client struct {}
+// Config holds the timeouts for each request, the content types accepted in
+// a successful response and the headers sent to each host. Headers for
+// AnyHost are sent to every host. CacheEntries bounds how many responses are
+// kept for HTTP caching, with zero turning it off. A body longer than
+// MaxBodySize is an error. Requests go through Proxy, other than to the hosts
+// in NoProxy, or the proxy given by the environment when Proxy is nil. TLS,
+// usually from LoadTLS, replaces the default TLS settings.
+type Config struct {
+	ConnectTimeout, ReadTimeout, Timeout time.Duration
+	ContentTypes                         []string
+	Headers                              map[string]http.Header
+	CacheEntries                         int
+	MaxBodySize                          int64
+	Proxy                                *url.URL
+	NoProxy                              string
+	TLS                                  *tls.Config
+}
+
+type client struct {
+	http         *http.Client
+	contentTypes []string
+	headers      map[string]http.Header
+	maxBodySize  int64
+}
+
+func (c client) Get(ctx context.Context, url string) (string, error) {
+	return readString(c.Open(ctx, url))
+}
+
+func (c client) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range headersFor(c.headers, req.URL.Host) {
+		req.Header[name] = values
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, newStatusError(resp, url)
+	}
+
+	if !acceptable(resp.Header.Get("Content-Type"), c.contentTypes) {
+		resp.Body.Close()
+		return nil, &ContentTypeError{
+			ContentType: resp.Header.Get("Content-Type"),
+			URL:         url,
+		}
+	}
+
+	if resp.ContentLength > c.maxBodySize {
+		resp.Body.Close()
+		return nil, &BodyTooLargeError{URL: url, Limit: c.maxBodySize}
+	}
+
+	return &boundedBody{
+		body:      resp.Body,
+		url:       url,
+		limit:     c.maxBodySize,
+		remaining: c.maxBodySize,
+	}, nil
+}
+
+// boundedBody fails a read which goes past the size limit, and closes the
+// response body as soon as it has been read to the end or a read fails
+type boundedBody struct {
+	body      io.ReadCloser
+	url       string
+	limit     int64
+	remaining int64
+	err       error
+}
 
-func (c client) Get(url string) (string, error) {
-	resp, err := http.Get(url)
+func (b *boundedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.body.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		err = &BodyTooLargeError{URL: b.url, Limit: b.limit}
+	}
+	b.remaining -= int64(n)
+
+	if err != nil {
+		b.err = err
+		b.body.Close()
+	}
+	return n, err
+}
+
+func (b *boundedBody) Close() error {
+	return b.body.Close()
+}
+
+// readString reads the whole of a body returned by Open, closing it
+func readString(body io.ReadCloser, err error) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	defer body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := ioutil.ReadAll(body)
 	if err != nil {
 		return "", err
 	}
-	return string(body), nil
+	return string(data), nil
+}
+
+// stringBody turns a body read by Get back into one which can be streamed
+func stringBody(body string, err error) (io.ReadCloser, error) {
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(strings.NewReader(body)), nil
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an
+// HTTP date, returning zero if there is none
+func parseRetryAfter(value string) time.Duration {
+	if len(value) < 1 {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(time.Now()) {
+		return time.Until(date)
+	}
+	return 0
 }
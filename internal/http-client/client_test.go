@@ -0,0 +1,152 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("HTTP client", func() {
+	var (
+		server  *httptest.Server
+		release chan struct{}
+	)
+
+	BeforeEach(func() {
+		release = make(chan struct{})
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/slow":
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+			case "/streamed":
+				w.Header().Set("Cache-Control", "max-age=60")
+				for i := 0; i < 4; i++ {
+					w.Write([]byte(strings.Repeat("x", 1024)))
+					w.(http.Flusher).Flush()
+				}
+				return
+			}
+			w.Write([]byte("some body"))
+		}))
+	})
+
+	AfterEach(func() {
+		close(release)
+		server.Close()
+	})
+
+	Context("Making a GET request", func() {
+		When("the server responds", func() {
+			It("should return the body", func() {
+				body, err := New(Config{}).Get(context.Background(), server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(Equal("some body"))
+			})
+		})
+
+		When("the server does not respond within the read timeout", func() {
+			It("should return an error", func() {
+				web := New(Config{ReadTimeout: time.Millisecond * 20})
+
+				start := time.Now()
+				_, err := web.Get(context.Background(), server.URL+"/slow")
+				Expect(err).To(HaveOccurred())
+				Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			})
+		})
+
+		When("the request takes longer than the total timeout", func() {
+			It("should return an error", func() {
+				web := New(Config{Timeout: time.Millisecond * 20})
+
+				_, err := web.Get(context.Background(), server.URL+"/slow")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("the context is cancelled during the request", func() {
+			It("should give up straight away", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(time.Millisecond*20, cancel)
+
+				_, err := New(Config{}).Get(ctx, server.URL+"/slow")
+				Expect(err).To(HaveOccurred())
+				Expect(ctx.Err()).To(Equal(context.Canceled))
+			})
+		})
+	})
+
+	Context("Streaming the body", func() {
+		When("the body is within the size limit", func() {
+			It("should read to the end and close the body", func() {
+				body, err := New(Config{MaxBodySize: 9}).Open(context.Background(), server.URL)
+				Expect(err).ToNot(HaveOccurred())
+
+				data, err := ioutil.ReadAll(body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(data)).To(Equal("some body"))
+
+				_, err = body.Read(make([]byte, 1))
+				Expect(err).To(Equal(io.EOF))
+				Expect(body.Close()).To(Succeed())
+			})
+		})
+
+		When("the Content-Length is over the size limit", func() {
+			It("should fail before reading the body", func() {
+				_, err := New(Config{MaxBodySize: 4}).Open(context.Background(), server.URL)
+
+				tooLarge := &BodyTooLargeError{}
+				Expect(errors.As(err, &tooLarge)).To(BeTrue())
+				Expect(tooLarge.Limit).To(Equal(int64(4)))
+				Expect(err.Error()).To(Equal(fmt.Sprintf(ErrorBodyTooLarge, server.URL, 4)))
+			})
+		})
+
+		When("a body with no Content-Length grows past the size limit", func() {
+			It("should fail once the limit has been read", func() {
+				body, err := New(Config{MaxBodySize: 2048}).Open(context.Background(), server.URL+"/streamed")
+				Expect(err).ToNot(HaveOccurred())
+				defer body.Close()
+
+				data, err := ioutil.ReadAll(body)
+				Expect(IsBodyTooLarge(err)).To(BeTrue())
+				Expect(data).To(HaveLen(2048))
+			})
+
+			It("should fail Get as well", func() {
+				_, err := New(Config{MaxBodySize: 2048}).Get(context.Background(), server.URL+"/streamed")
+				Expect(IsBodyTooLarge(err)).To(BeTrue())
+				Expect(retryable(err)).To(BeFalse())
+			})
+		})
+
+		When("the body is cached", func() {
+			It("should not cache a body over the size limit", func() {
+				web := New(Config{MaxBodySize: 2048, CacheEntries: 10})
+				_, err := web.Get(context.Background(), server.URL+"/streamed")
+				Expect(IsBodyTooLarge(err)).To(BeTrue())
+
+				_, err = web.Get(context.Background(), server.URL+"/streamed")
+				Expect(IsBodyTooLarge(err)).To(BeTrue())
+			})
+		})
+	})
+})
@@ -0,0 +1,147 @@
+package httpClient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ErrorStatus        = "Unexpected HTTP status %d from %s"
+	ErrorStatusProblem = "Unexpected HTTP status %d from %s: %s"
+	ErrorContentType   = "Unexpected content type %q from %s"
+	ErrorBodyTooLarge  = "The response from %s is larger than the limit of %d bytes"
+
+	problemBodyLimit = 64 * 1024
+)
+
+// Problem holds the RFC 7807 problem details which api.weather.gov gives
+// with an error response
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+// StatusError is returned for any response outside the 2xx range. Problem is
+// only set when the response carried problem details.
+type StatusError struct {
+	Code       int
+	URL        string
+	RetryAfter time.Duration
+	Problem    *Problem
+}
+
+func (e *StatusError) Error() string {
+	if e.Problem != nil {
+		description := e.Problem.Title
+		if len(e.Problem.Detail) > 0 {
+			description = e.Problem.Detail
+		}
+
+		if len(description) > 0 {
+			return fmt.Sprintf(ErrorStatusProblem, e.Code, e.URL, description)
+		}
+	}
+	return fmt.Sprintf(ErrorStatus, e.Code, e.URL)
+}
+
+// ContentTypeError is returned for a successful response whose content type
+// is not one the client was configured to accept
+type ContentTypeError struct {
+	ContentType string
+	URL         string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf(ErrorContentType, e.ContentType, e.URL)
+}
+
+// BodyTooLargeError is returned when a response body is longer than the
+// client's maximum body size, either from its Content-Length or once that
+// many bytes have been read
+type BodyTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf(ErrorBodyTooLarge, e.URL, e.Limit)
+}
+
+// IsBodyTooLarge reports whether err was caused by a response body longer
+// than the maximum body size
+func IsBodyTooLarge(err error) bool {
+	tooLarge := &BodyTooLargeError{}
+	return errors.As(err, &tooLarge)
+}
+
+// IsNotFound reports whether err was caused by a 404 or 410 response
+func IsNotFound(err error) bool {
+	status := &StatusError{}
+	return errors.As(err, &status) && (status.Code == http.StatusNotFound || status.Code == http.StatusGone)
+}
+
+// IsRateLimited reports whether err was caused by a 429 response
+func IsRateLimited(err error) bool {
+	status := &StatusError{}
+	return errors.As(err, &status) && status.Code == http.StatusTooManyRequests
+}
+
+// IsServerError reports whether err was caused by a 5xx response
+func IsServerError(err error) bool {
+	status := &StatusError{}
+	return errors.As(err, &status) && status.Code >= 500
+}
+
+func newStatusError(resp *http.Response, url string) *StatusError {
+	statusErr := &StatusError{
+		Code:       resp.StatusCode,
+		URL:        url,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/problem+json" && mediaType != "application/json" {
+		return statusErr
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, problemBodyLimit))
+	if err != nil {
+		return statusErr
+	}
+
+	problem := Problem{}
+	if err := json.Unmarshal(body, &problem); err == nil && (len(problem.Title) > 0 || len(problem.Detail) > 0) {
+		statusErr.Problem = &problem
+	}
+	return statusErr
+}
+
+// acceptable reports whether a response's Content-Type header matches one of
+// the accepted media types. An empty list accepts anything.
+func acceptable(contentType string, accepted []string) bool {
+	if len(accepted) < 1 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, accept := range accepted {
+		if strings.EqualFold(mediaType, accept) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("HTTP client errors", func() {
+	var (
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/problem":
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"type":"https://api.weather.gov/problems/InvalidPoint","title":"Data Unavailable For Requested Point","status":404,"detail":"Unable to provide data for requested point 51.5,-0.12"}`))
+			case "/html":
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte("<html>oops</html>"))
+			case "/limited":
+				w.WriteHeader(http.StatusTooManyRequests)
+			default:
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write([]byte("<html>not json</html>"))
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("Reading error responses", func() {
+		When("the server responds with problem details", func() {
+			It("should return a status error carrying them", func() {
+				_, err := New(Config{}).Get(context.Background(), server.URL+"/problem")
+
+				status := &StatusError{}
+				Expect(errors.As(err, &status)).To(BeTrue())
+				Expect(status.Code).To(Equal(http.StatusNotFound))
+				Expect(status.URL).To(Equal(server.URL + "/problem"))
+				Expect(status.Problem.Title).To(Equal("Data Unavailable For Requested Point"))
+				Expect(status.Problem.Type).To(Equal("https://api.weather.gov/problems/InvalidPoint"))
+				Expect(err.Error()).To(Equal(fmt.Sprintf(ErrorStatusProblem, 404, server.URL+"/problem", "Unable to provide data for requested point 51.5,-0.12")))
+				Expect(IsNotFound(err)).To(BeTrue())
+				Expect(IsServerError(err)).To(BeFalse())
+			})
+		})
+
+		When("the server responds with an HTML error page", func() {
+			It("should return a status error without problem details", func() {
+				_, err := New(Config{}).Get(context.Background(), server.URL+"/html")
+
+				status := &StatusError{}
+				Expect(errors.As(err, &status)).To(BeTrue())
+				Expect(status.Problem).To(BeNil())
+				Expect(err.Error()).To(Equal(fmt.Sprintf(ErrorStatus, 500, server.URL+"/html")))
+				Expect(IsServerError(err)).To(BeTrue())
+			})
+		})
+
+		When("the server is rate limiting", func() {
+			It("should be recognised as rate limited", func() {
+				_, err := New(Config{}).Get(context.Background(), server.URL+"/limited")
+				Expect(IsRateLimited(fmt.Errorf("wrapped: %w", err))).To(BeTrue())
+			})
+		})
+	})
+
+	Context("Checking the content type", func() {
+		When("a successful response is not of an accepted type", func() {
+			It("should return a content type error", func() {
+				_, err := New(Config{ContentTypes: JSONContentTypes}).Get(context.Background(), server.URL)
+
+				contentType := &ContentTypeError{}
+				Expect(errors.As(err, &contentType)).To(BeTrue())
+				Expect(contentType.ContentType).To(Equal("text/html; charset=utf-8"))
+			})
+		})
+
+		When("no content types are configured", func() {
+			It("should accept any response", func() {
+				body, err := New(Config{}).Get(context.Background(), server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(Equal("<html>not json</html>"))
+			})
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package httpClient
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	ErrorNoFixture    = "No fixture has been recorded for %s"
+	ErrorReadFixture  = "Could not read the fixture for %s: %s"
+	ErrorWriteFixture = "Could not write the fixture for %s: %s"
+	ErrorMode         = "Unknown HTTP mode %q, expected live, record or replay"
+
+	ModeLive   = "live"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+var (
+	unsafeFileName = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// Fixture is one recorded exchange with an upstream service. A response
+// outside the 2xx range is recorded by its status and problem details so it
+// replays as the same StatusError.
+type Fixture struct {
+	URL     string   `json:"url"`
+	Status  int      `json:"status,omitempty"`
+	Problem *Problem `json:"problem,omitempty"`
+	Body    string   `json:"body,omitempty"`
+}
+
+// recorder writes a fixture for every successful response, and every error
+// response, from the client it wraps. Connection errors are not recorded.
+type recorder struct {
+	client Client
+	dir    string
+}
+
+func (r recorder) Get(ctx context.Context, rawURL string) (string, error) {
+	body, err := r.client.Get(ctx, rawURL)
+
+	fixture := Fixture{URL: rawURL, Body: body}
+	status := &StatusError{}
+	switch {
+	case err == nil:
+	case errors.As(err, &status):
+		fixture.Status = status.Code
+		fixture.Problem = status.Problem
+	default:
+		return body, err
+	}
+
+	if writeErr := writeFixture(r.dir, fixture); writeErr != nil {
+		return "", writeErr
+	}
+	return body, err
+}
+
+// Open reads the whole body so it can be recorded before the caller sees it
+func (r recorder) Open(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	return stringBody(r.Get(ctx, rawURL))
+}
+
+// replayer answers every request from the fixtures recorded for it, without
+// contacting any upstream service
+type replayer struct {
+	dir string
+}
+
+func (r replayer) Get(ctx context.Context, rawURL string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(r.dir, fixtureName(rawURL)))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf(ErrorNoFixture, rawURL)
+	}
+	if err != nil {
+		return "", fmt.Errorf(ErrorReadFixture, rawURL, err.Error())
+	}
+
+	fixture := Fixture{}
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return "", fmt.Errorf(ErrorReadFixture, rawURL, err.Error())
+	}
+
+	if fixture.Status != 0 {
+		return "", &StatusError{Code: fixture.Status, URL: rawURL, Problem: fixture.Problem}
+	}
+	return fixture.Body, nil
+}
+
+func (r replayer) Open(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	return stringBody(r.Get(ctx, rawURL))
+}
+
+func writeFixture(dir string, fixture Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf(ErrorWriteFixture, fixture.URL, err.Error())
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf(ErrorWriteFixture, fixture.URL, err.Error())
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, fixtureName(fixture.URL)), data, 0600); err != nil {
+		return fmt.Errorf(ErrorWriteFixture, fixture.URL, err.Error())
+	}
+	return nil
+}
+
+// fixtureName names the fixture for a URL after its host and path, so the
+// fixture directory can be browsed, along with a hash of the URL with its
+// query parameters sorted, so requests match regardless of parameter order
+func fixtureName(rawURL string) string {
+	matched := rawURL
+	readable := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		parsed.RawQuery = parsed.Query().Encode()
+		parsed.Fragment = ""
+		matched = parsed.String()
+		readable = parsed.Host + parsed.Path
+	}
+
+	hash := sha1.Sum([]byte(matched))
+	readable = strings.Trim(unsafeFileName.ReplaceAllString(strings.ToLower(readable), "-"), "-.")
+	if len(readable) > 80 {
+		readable = readable[:80]
+	}
+	return readable + "-" + hex.EncodeToString(hash[:6]) + ".json"
+}
@@ -0,0 +1,107 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("Recording and replaying fixtures", func() {
+	var (
+		dir    string
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fixtures")
+		Expect(err).ToNot(HaveOccurred())
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/missing":
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"title":"Not Found","detail":"Unable to provide data for requested point"}`))
+			default:
+				w.Write([]byte(fmt.Sprintf(`{"query":%q}`, r.URL.RawQuery)))
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	When("a successful exchange is recorded", func() {
+		It("should replay the same body without contacting the server", func() {
+			body, err := NewRecording(New(Config{}), dir).Get(context.Background(), server.URL+"/search?q=chicago&format=json")
+			Expect(err).ToNot(HaveOccurred())
+			server.Close()
+
+			replayed, err := NewReplaying(dir).Get(context.Background(), server.URL+"/search?q=chicago&format=json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replayed).To(Equal(body))
+		})
+
+		It("should match the request whatever order its parameters are in", func() {
+			NewRecording(New(Config{}), dir).Get(context.Background(), server.URL+"/search?q=chicago&format=json")
+
+			replayed, err := NewReplaying(dir).Get(context.Background(), server.URL+"/search?format=json&q=chicago")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replayed).To(Equal(`{"query":"q=chicago&format=json"}`))
+		})
+
+		It("should name the fixture after the request", func() {
+			NewRecording(New(Config{}), dir).Get(context.Background(), server.URL+"/search?q=chicago")
+
+			files, err := filepath.Glob(filepath.Join(dir, "127.0.0.1-*-search-*.json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+		})
+	})
+
+	When("an error response is recorded", func() {
+		It("should replay it as the same status error", func() {
+			_, err := NewRecording(New(Config{}), dir).Get(context.Background(), server.URL+"/missing")
+			Expect(IsNotFound(err)).To(BeTrue())
+
+			_, err = NewReplaying(dir).Get(context.Background(), server.URL+"/missing")
+			status := &StatusError{}
+			Expect(errors.As(err, &status)).To(BeTrue())
+			Expect(status.Code).To(Equal(http.StatusNotFound))
+			Expect(status.Problem.Detail).To(Equal("Unable to provide data for requested point"))
+		})
+	})
+
+	When("the server cannot be connected to", func() {
+		It("should not record a fixture", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			addr := listener.Addr().String()
+			listener.Close()
+
+			_, err = NewRecording(New(Config{}), dir).Get(context.Background(), "http://"+addr)
+			Expect(err).To(HaveOccurred())
+
+			files, _ := ioutil.ReadDir(dir)
+			Expect(files).To(BeEmpty())
+		})
+	})
+
+	When("no fixture has been recorded for a request", func() {
+		It("should return an error", func() {
+			_, err := NewReplaying(dir).Get(context.Background(), server.URL+"/search?q=nowhere")
+			Expect(err).To(MatchError(fmt.Sprintf(ErrorNoFixture, server.URL+"/search?q=nowhere")))
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package httpClient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	ErrorHeaderEntry = "Could not read the header: %s"
+
+	WeatherHost = "api.weather.gov"
+	AnyHost     = "*"
+
+	DefaultUserAgent = "tech-test-ennismore (https://github.com/jddcode/tech-test-01)"
+)
+
+var (
+	// DefaultHeaders asks the NWS for GeoJSON, which is what the weather
+	// fetcher reads
+	DefaultHeaders = map[string]http.Header{
+		WeatherHost: http.Header{"Accept": []string{"application/geo+json"}},
+	}
+)
+
+// headersFor returns the headers to send to the host: those configured for
+// AnyHost, overridden by any configured for the host itself
+func headersFor(headers map[string]http.Header, host string) http.Header {
+	combined := http.Header{}
+	for _, key := range []string{AnyHost, strings.ToLower(host)} {
+		for name, values := range headers[key] {
+			combined[name] = values
+		}
+	}
+	return combined
+}
+
+// MergeHeaders combines sets of headers for each host, with a header given in
+// a later set replacing the same header for the same host in an earlier one
+func MergeHeaders(sets ...map[string]http.Header) map[string]http.Header {
+	merged := make(map[string]http.Header)
+	for _, set := range sets {
+		for host, headers := range set {
+			host = strings.ToLower(host)
+			if _, exists := merged[host]; !exists {
+				merged[host] = http.Header{}
+			}
+
+			for name, values := range headers {
+				merged[host][http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+			}
+		}
+	}
+	return merged
+}
+
+// ParseHeaders reads a semicolon delimited list of headers for each host, eg.
+// "api.weather.gov=Feature-Flags: forecast_temperature_qv;*=From: ops@example.com"
+// where a host of * applies the header to every host
+func ParseHeaders(list string) (map[string]http.Header, error) {
+	headers := make(map[string]http.Header)
+	for _, item := range strings.Split(list, ";") {
+		if len(strings.TrimSpace(item)) < 1 {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) < 1 {
+			return nil, fmt.Errorf(ErrorHeaderEntry, item)
+		}
+
+		header := strings.SplitN(parts[1], ":", 2)
+		if len(header) != 2 || len(strings.TrimSpace(header[0])) < 1 || len(strings.TrimSpace(header[1])) < 1 {
+			return nil, fmt.Errorf(ErrorHeaderEntry, item)
+		}
+
+		host := strings.ToLower(strings.TrimSpace(parts[0]))
+		if _, exists := headers[host]; !exists {
+			headers[host] = http.Header{}
+		}
+		headers[host].Add(strings.TrimSpace(header[0]), strings.TrimSpace(header[1]))
+	}
+	return headers, nil
+}
@@ -0,0 +1,101 @@
+package httpClient
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+var _ = Describe("Request headers", func() {
+	var (
+		server   *httptest.Server
+		received http.Header
+		host     string
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+			w.Write([]byte("some body"))
+		}))
+		parsed, _ := url.Parse(server.URL)
+		host = parsed.Host
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("Sending headers", func() {
+		When("no headers are configured", func() {
+			It("should identify the service in the User-Agent", func() {
+				_, err := New(Config{}).Get(context.Background(), server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(received.Get("User-Agent")).To(Equal(DefaultUserAgent))
+			})
+		})
+
+		When("headers are configured for every host and for this host", func() {
+			It("should send both, preferring those for this host", func() {
+				web := New(Config{Headers: map[string]http.Header{
+					AnyHost: http.Header{"User-Agent": []string{"everywhere (ops@example.com)"}, "From": []string{"ops@example.com"}},
+					host:    http.Header{"User-Agent": []string{"here (ops@example.com)"}, "Feature-Flags": []string{"forecast_temperature_qv"}},
+				}})
+				_, err := web.Get(context.Background(), server.URL)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(received.Get("User-Agent")).To(Equal("here (ops@example.com)"))
+				Expect(received.Get("From")).To(Equal("ops@example.com"))
+				Expect(received.Get("Feature-Flags")).To(Equal("forecast_temperature_qv"))
+			})
+		})
+
+		When("headers are configured for another host", func() {
+			It("should not send them", func() {
+				web := New(Config{Headers: MergeHeaders(DefaultHeaders)})
+				_, err := web.Get(context.Background(), server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(received.Get("Accept")).ToNot(Equal("application/geo+json"))
+			})
+		})
+	})
+
+	Context("Merging headers", func() {
+		It("should let later sets replace earlier headers for the same host", func() {
+			merged := MergeHeaders(
+				map[string]http.Header{"API.weather.gov": http.Header{"Accept": []string{"application/geo+json"}, "User-Agent": []string{"first"}}},
+				map[string]http.Header{"api.weather.gov": http.Header{"user-agent": []string{"second"}}},
+			)
+
+			Expect(merged).To(Equal(map[string]http.Header{
+				"api.weather.gov": http.Header{"Accept": []string{"application/geo+json"}, "User-Agent": []string{"second"}},
+			}))
+		})
+	})
+
+	Context("Reading headers", func() {
+		It("should read each host's headers", func() {
+			headers, err := ParseHeaders("API.weather.gov=Feature-Flags: forecast_temperature_qv; api.weather.gov = feature-flags: forecast_wind_speed_qv;*=From: ops@example.com;")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(headers).To(Equal(map[string]http.Header{
+				"api.weather.gov": http.Header{"Feature-Flags": []string{"forecast_temperature_qv", "forecast_wind_speed_qv"}},
+				AnyHost:           http.Header{"From": []string{"ops@example.com"}},
+			}))
+		})
+
+		DescribeTable("rejecting malformed headers",
+			func(list string) {
+				_, err := ParseHeaders(list)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("a missing header", "example.org"),
+			Entry("a missing host", "=From: ops@example.com"),
+			Entry("a missing value", "example.org=From:"),
+			Entry("a missing name", "example.org=: ops@example.com"),
+		)
+	})
+})
@@ -0,0 +1,193 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ErrorRateLimit      = "Requests to %s are rate limited and the next could not be sent for %s"
+	ErrorRateLimitEntry = "Could not read the rate limit: %s"
+
+	NominatimHost = "nominatim.openstreetmap.org"
+)
+
+var (
+	// DefaultRateLimits keeps to the Nominatim usage policy of at most one
+	// request per second
+	DefaultRateLimits = map[string]RateLimit{
+		NominatimHost: RateLimit{Every: time.Second, Burst: 1},
+	}
+)
+
+// RateLimit allows one request to a host every Every, with up to Burst
+// requests at once after a quiet spell. A zero Every does not limit the host.
+type RateLimit struct {
+	Every time.Duration
+	Burst int
+}
+
+// LimiterConfig gives the rate limit for each host, with Default applied to
+// every other host. A request which would have to wait longer than MaxWait,
+// or past the deadline of its context, is rejected rather than queued. A zero
+// MaxWait leaves it to the deadline alone.
+type LimiterConfig struct {
+	Default RateLimit
+	Hosts   map[string]RateLimit
+	MaxWait time.Duration
+}
+
+// RateLimitError is returned without making a request when the rate limit for
+// the host would hold it back for longer than the caller can wait
+type RateLimitError struct {
+	Host string
+	Wait time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf(ErrorRateLimit, e.Host, e.Wait)
+}
+
+// IsThrottled reports whether err was caused by our own rate limit, rather
+// than the server's
+func IsThrottled(err error) bool {
+	limited := &RateLimitError{}
+	return errors.As(err, &limited)
+}
+
+type bucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token from the bucket, returning how long the request must
+// wait for it. The bucket may go into debt, which is how queued requests are
+// spaced out behind each other.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.tokens += float64(now.Sub(b.last)) / float64(b.limit.Every)
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.limit.Every))
+}
+
+// limiter keeps a token bucket for each upstream host, holding each request
+// back until its host's bucket has a token for it
+type limiter struct {
+	client  Client
+	config  LimiterConfig
+	buckets map[string]*bucket
+	lock    sync.Mutex
+}
+
+func (l *limiter) Get(ctx context.Context, rawURL string) (string, error) {
+	if err := l.wait(ctx, rawURL); err != nil {
+		return "", err
+	}
+	return l.client.Get(ctx, rawURL)
+}
+
+func (l *limiter) Open(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	if err := l.wait(ctx, rawURL); err != nil {
+		return nil, err
+	}
+	return l.client.Open(ctx, rawURL)
+}
+
+func (l *limiter) wait(ctx context.Context, rawURL string) error {
+	host := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		host = strings.ToLower(parsed.Host)
+	}
+
+	limit, exists := l.config.Hosts[host]
+	if !exists {
+		limit = l.config.Default
+	}
+
+	if limit.Every <= 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	current, exists := l.buckets[host]
+	if !exists {
+		current = &bucket{limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+		l.buckets[host] = current
+	}
+
+	now := time.Now()
+	delay := current.reserve(now)
+	deadline, bounded := ctx.Deadline()
+	if l.config.MaxWait > 0 && delay > l.config.MaxWait || bounded && now.Add(delay).After(deadline) {
+		current.tokens++
+		l.lock.Unlock()
+		return &RateLimitError{Host: host, Wait: delay}
+	}
+	l.lock.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.lock.Lock()
+		current.tokens++
+		l.lock.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+	}
+	return nil
+}
+
+// ParseRateLimits reads a semicolon delimited list of rate limits for each
+// host, given as the interval between requests and optionally the burst, eg.
+// "nominatim.openstreetmap.org=1s;api.weather.gov=100ms/5"
+func ParseRateLimits(list string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, item := range strings.Split(list, ";") {
+		if len(strings.TrimSpace(item)) < 1 {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) < 1 {
+			return nil, fmt.Errorf(ErrorRateLimitEntry, item)
+		}
+
+		limit := RateLimit{Burst: 1}
+		every := strings.TrimSpace(parts[1])
+		if slash := strings.Index(every, "/"); slash > -1 {
+			burst, err := strconv.Atoi(strings.TrimSpace(every[slash+1:]))
+			if err != nil || burst < 1 {
+				return nil, fmt.Errorf(ErrorRateLimitEntry, item)
+			}
+			limit.Burst = burst
+			every = strings.TrimSpace(every[:slash])
+		}
+
+		var err error
+		if limit.Every, err = time.ParseDuration(every); err != nil || limit.Every < 0 {
+			return nil, fmt.Errorf(ErrorRateLimitEntry, item)
+		}
+		limits[strings.ToLower(strings.TrimSpace(parts[0]))] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,175 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Rate limited HTTP client", func() {
+	var (
+		sent     []time.Time
+		upstream clientFunc
+		config   LimiterConfig
+	)
+
+	BeforeEach(func() {
+		sent = nil
+		upstream = func(ctx context.Context, url string) (string, error) {
+			sent = append(sent, time.Now())
+			return "some body", nil
+		}
+		config = LimiterConfig{
+			Hosts: map[string]RateLimit{
+				"limited.example": RateLimit{Every: time.Millisecond * 50},
+			},
+		}
+	})
+
+	Context("Queueing requests", func() {
+		When("requests to a limited host arrive together", func() {
+			It("should space them out", func() {
+				web := NewLimited(upstream, config)
+				for i := 0; i < 3; i++ {
+					_, err := web.Get(context.Background(), "http://limited.example/a")
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				Expect(sent).To(HaveLen(3))
+				Expect(sent[2].Sub(sent[0])).To(BeNumerically(">=", time.Millisecond*95))
+			})
+		})
+
+		When("the host allows a burst", func() {
+			It("should send the burst straight away", func() {
+				config.Hosts["limited.example"] = RateLimit{Every: time.Hour, Burst: 3}
+				web := NewLimited(upstream, config)
+
+				start := time.Now()
+				for i := 0; i < 3; i++ {
+					web.Get(context.Background(), "http://limited.example/a")
+				}
+				Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			})
+		})
+
+		When("the host has no limit", func() {
+			It("should send requests straight away", func() {
+				config.Hosts["limited.example"] = RateLimit{Every: time.Hour}
+				web := NewLimited(upstream, config)
+				web.Get(context.Background(), "http://limited.example/a")
+
+				start := time.Now()
+				for i := 0; i < 5; i++ {
+					_, err := web.Get(context.Background(), "http://other.example/a")
+					Expect(err).ToNot(HaveOccurred())
+				}
+				Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			})
+		})
+
+		When("the host is given in a different case", func() {
+			It("should apply its limit", func() {
+				config.Hosts = map[string]RateLimit{"Limited.Example": RateLimit{Every: time.Hour}}
+				web := NewLimited(upstream, config)
+				web.Get(context.Background(), "http://limited.example/a")
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				_, err := web.Get(ctx, "http://LIMITED.example/a")
+				Expect(IsThrottled(err)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("Requests to limited.example are rate limited"))
+			})
+		})
+	})
+
+	Context("Rejecting requests", func() {
+		When("the wait would pass the caller's deadline", func() {
+			It("should fail straight away without sending the request", func() {
+				config.Hosts["limited.example"] = RateLimit{Every: time.Hour}
+				web := NewLimited(upstream, config)
+				web.Get(context.Background(), "http://limited.example/a")
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				start := time.Now()
+				_, err := web.Get(ctx, "http://limited.example/a")
+
+				limited := &RateLimitError{}
+				Expect(errors.As(err, &limited)).To(BeTrue())
+				Expect(limited.Host).To(Equal("limited.example"))
+				Expect(limited.Wait).To(BeNumerically(">", time.Minute*59))
+				Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*100))
+				Expect(sent).To(HaveLen(1))
+			})
+
+			It("should not hold back the requests after it", func() {
+				web := NewLimited(upstream, config)
+				web.Get(context.Background(), "http://limited.example/a")
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				defer cancel()
+				_, err := web.Get(ctx, "http://limited.example/a")
+				Expect(IsThrottled(err)).To(BeTrue())
+
+				start := time.Now()
+				web.Get(context.Background(), "http://limited.example/a")
+				Expect(time.Since(start)).To(BeNumerically("<", time.Millisecond*90))
+			})
+		})
+
+		When("the wait would be longer than the maximum", func() {
+			It("should fail straight away", func() {
+				config.Hosts["limited.example"] = RateLimit{Every: time.Hour}
+				config.MaxWait = time.Second
+				web := NewLimited(upstream, config)
+				web.Get(context.Background(), "http://limited.example/a")
+
+				_, err := web.Get(context.Background(), "http://limited.example/a")
+				Expect(IsThrottled(err)).To(BeTrue())
+				Expect(retryable(err)).To(BeFalse())
+			})
+		})
+
+		When("the caller gives up while queued", func() {
+			It("should return the context's error", func() {
+				config.Hosts["limited.example"] = RateLimit{Every: time.Hour}
+				web := NewLimited(upstream, config)
+				web.Get(context.Background(), "http://limited.example/a")
+
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(time.Millisecond*20, cancel)
+				_, err := web.Get(ctx, "http://limited.example/a")
+				Expect(err).To(Equal(context.Canceled))
+				Expect(sent).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("Reading rate limits", func() {
+		It("should read each host's interval and burst", func() {
+			limits, err := ParseRateLimits("Nominatim.OpenStreetMap.org=1s; api.weather.gov = 100ms/5;")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limits).To(Equal(map[string]RateLimit{
+				"nominatim.openstreetmap.org": RateLimit{Every: time.Second, Burst: 1},
+				"api.weather.gov":             RateLimit{Every: time.Millisecond * 100, Burst: 5},
+			}))
+		})
+
+		DescribeTable("rejecting malformed limits",
+			func(list string) {
+				_, err := ParseRateLimits(list)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("a missing interval", "example.org"),
+			Entry("a missing host", "=1s"),
+			Entry("an invalid interval", "example.org=fast"),
+			Entry("a negative interval", "example.org=-1s"),
+			Entry("an invalid burst", "example.org=1s/none"),
+			Entry("a zero burst", "example.org=1s/0"),
+		)
+	})
+})
@@ -1,5 +1,176 @@
 package httpClient
 
-func New() Client {
-	return client{}
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultConnectTimeout = time.Second * 5
+	DefaultReadTimeout    = time.Second * 10
+	DefaultTimeout        = time.Second * 15
+	DefaultMaxBodySize    = 8 << 20
+)
+
+var (
+	JSONContentTypes = []string{"application/json", "application/geo+json", "application/ld+json"}
+)
+
+// New creates a Client whose requests give up after the configured timeouts:
+// ConnectTimeout for the connection and TLS handshake, ReadTimeout waiting
+// for the response headers and Timeout for the whole request including the
+// body. A request is also abandoned as soon as its context is cancelled. If
+// ContentTypes is set any successful response of another type is an error.
+// Every request carries a User-Agent, DefaultUserAgent unless Headers gives
+// another. Responses are cached as their Cache-Control allows when
+// CacheEntries is set. Bodies are limited to MaxBodySize, which defaults to
+// DefaultMaxBodySize.
+func New(config Config) Client {
+	config = config.withDefaults()
+	dialer := &net.Dialer{
+		Timeout:   config.ConnectTimeout,
+		KeepAlive: time.Second * 30,
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		proxy = proxyFunc(config.Proxy, config.NoProxy)
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       config.TLS,
+		TLSHandshakeTimeout:   config.ConnectTimeout,
+		ResponseHeaderTimeout: config.ReadTimeout,
+		MaxIdleConnsPerHost:   8,
+		IdleConnTimeout:       time.Second * 90,
+	}
+	if config.CacheEntries > 0 {
+		transport = newCachingTransport(transport, config.CacheEntries, config.MaxBodySize)
+	}
+
+	return client{
+		http: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+		},
+		contentTypes: config.ContentTypes,
+		headers:      config.Headers,
+		maxBodySize:  config.MaxBodySize,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = DefaultConnectTimeout
+	}
+
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = DefaultReadTimeout
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+
+	if c.MaxBodySize <= 0 {
+		c.MaxBodySize = DefaultMaxBodySize
+	}
+
+	c.Headers = MergeHeaders(map[string]http.Header{
+		AnyHost: http.Header{"User-Agent": []string{DefaultUserAgent}},
+	}, c.Headers)
+	return c
+}
+
+// NewRetrying wraps a Client so failures which may be temporary are retried
+// with an exponential backoff
+func NewRetrying(client Client, config RetryConfig) Client {
+	return retryClient{
+		client: client,
+		config: config.withDefaults(),
+	}
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.Attempts < 1 {
+		c.Attempts = DefaultRetryAttempts
+	}
+
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = DefaultRetryBaseDelay
+	}
+
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = DefaultRetryMaxDelay
+	}
+	return c
+}
+
+// NewBreaker wraps a Client with a circuit breaker for each upstream host, so
+// requests to a host which keeps failing fail straight away for a while
+func NewBreaker(client Client, config BreakerConfig) Breaker {
+	return &breaker{
+		client:   client,
+		config:   config.withDefaults(),
+		circuits: make(map[string]*circuit),
+	}
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.Failures < 1 {
+		c.Failures = DefaultBreakerFailures
+	}
+
+	if c.CoolDown <= 0 {
+		c.CoolDown = DefaultBreakerCoolDown
+	}
+	return c
+}
+
+// NewLimited wraps a Client with a token bucket rate limit for each upstream
+// host, queueing requests until they may be sent
+func NewLimited(client Client, config LimiterConfig) Client {
+	return &limiter{
+		client:  client,
+		config:  config.withDefaults(),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (c LimiterConfig) withDefaults() LimiterConfig {
+	c.Default = c.Default.withDefaults()
+	hosts := make(map[string]RateLimit, len(c.Hosts))
+	for host, limit := range c.Hosts {
+		hosts[strings.ToLower(host)] = limit.withDefaults()
+	}
+	c.Hosts = hosts
+	return c
+}
+
+func (l RateLimit) withDefaults() RateLimit {
+	if l.Burst < 1 {
+		l.Burst = 1
+	}
+	return l
+}
+
+// NewRecording wraps a Client so each exchange with an upstream service is
+// written to a fixture file in dir, to be replayed later by NewReplaying
+func NewRecording(client Client, dir string) Client {
+	return recorder{
+		client: client,
+		dir:    dir,
+	}
+}
+
+// NewReplaying creates a Client which answers from the fixtures recorded in
+// dir by NewRecording, failing any request which has no fixture
+func NewReplaying(dir string) Client {
+	return replayer{
+		dir: dir,
+	}
 }
@@ -0,0 +1,59 @@
+package httpClient
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyFunc sends every request through the proxy, other than those to a host
+// matched by noProxy, a comma delimited list in the same form as the NO_PROXY
+// environment variable: host names, which also match their subdomains, IP
+// addresses, CIDR ranges, any of them with a port, or * to match everything
+func proxyFunc(proxy *url.URL, noProxy string) func(*http.Request) (*url.URL, error) {
+	var exclusions []string
+	for _, entry := range strings.Split(noProxy, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); len(entry) > 0 {
+			exclusions = append(exclusions, entry)
+		}
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		for _, exclusion := range exclusions {
+			if bypasses(exclusion, req.URL) {
+				return nil, nil
+			}
+		}
+		return proxy, nil
+	}
+}
+
+func bypasses(exclusion string, target *url.URL) bool {
+	if exclusion == "*" {
+		return true
+	}
+
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if len(port) < 1 && target.Scheme == "https" {
+		port = "443"
+	} else if len(port) < 1 {
+		port = "80"
+	}
+
+	if _, network, err := net.ParseCIDR(exclusion); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+
+	if excludedHost, excludedPort, err := net.SplitHostPort(exclusion); err == nil {
+		if excludedPort != port {
+			return false
+		}
+		exclusion = excludedHost
+	}
+
+	exclusion = strings.TrimPrefix(strings.TrimPrefix(exclusion, "*"), ".")
+	return host == exclusion || strings.HasSuffix(host, "."+exclusion)
+}
@@ -0,0 +1,86 @@
+package httpClient
+
+import (
+	"context"
+	"encoding/base64"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+)
+
+var _ = Describe("Outbound proxy", func() {
+	var (
+		target   *httptest.Server
+		proxy    *httptest.Server
+		proxyURL *url.URL
+		proxied  []string
+		auth     []string
+		lock     sync.Mutex
+	)
+
+	BeforeEach(func() {
+		proxied = nil
+		auth = nil
+		target = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("direct"))
+		}))
+
+		proxy = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			proxied = append(proxied, r.URL.String())
+			auth = append(auth, r.Header.Get("Proxy-Authorization"))
+			lock.Unlock()
+			w.Write([]byte("via proxy"))
+		}))
+
+		proxyURL, _ = url.Parse(proxy.URL)
+		proxyURL.User = url.UserPassword("user", "secret")
+	})
+
+	AfterEach(func() {
+		target.Close()
+		proxy.Close()
+	})
+
+	When("a proxy is configured", func() {
+		It("should send requests through it with its credentials", func() {
+			body, err := New(Config{Proxy: proxyURL}).Get(context.Background(), target.URL+"/points")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(Equal("via proxy"))
+
+			Expect(proxied).To(Equal([]string{target.URL + "/points"}))
+			Expect(auth).To(Equal([]string{"Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))}))
+		})
+	})
+
+	When("the host is excluded from the proxy", func() {
+		It("should connect to it directly", func() {
+			body, err := New(Config{Proxy: proxyURL, NoProxy: "example.org, 127.0.0.1"}).Get(context.Background(), target.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(Equal("direct"))
+			Expect(proxied).To(BeEmpty())
+		})
+	})
+
+	DescribeTable("matching hosts to exclude from the proxy",
+		func(exclusion, target string, expected bool) {
+			parsed, _ := url.Parse(target)
+			Expect(bypasses(exclusion, parsed)).To(Equal(expected))
+		},
+		Entry("everything", "*", "https://api.weather.gov/points", true),
+		Entry("the same host", "api.weather.gov", "https://api.weather.gov/points", true),
+		Entry("a subdomain", "weather.gov", "https://api.weather.gov/points", true),
+		Entry("a subdomain with a leading dot", ".weather.gov", "https://api.weather.gov/points", true),
+		Entry("a subdomain with a leading wildcard", "*.weather.gov", "https://api.weather.gov/points", true),
+		Entry("a different host", "weather.gov", "https://nominatim.openstreetmap.org/search", false),
+		Entry("a host which only shares a suffix", "ther.gov", "https://api.weather.gov/points", false),
+		Entry("the same host and port", "api.weather.gov:443", "https://api.weather.gov/points", true),
+		Entry("the same host on another port", "api.weather.gov:8443", "https://api.weather.gov/points", false),
+		Entry("an address in a CIDR range", "10.0.0.0/8", "http://10.1.2.3/points", true),
+		Entry("an address outside a CIDR range", "10.0.0.0/8", "http://192.168.1.1/points", false),
+	)
+})
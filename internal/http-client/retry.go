@@ -0,0 +1,118 @@
+package httpClient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultRetryAttempts  = 3
+	DefaultRetryBaseDelay = time.Millisecond * 200
+	DefaultRetryMaxDelay  = time.Second * 5
+)
+
+type RetryConfig struct {
+	Attempts            int
+	BaseDelay, MaxDelay time.Duration
+}
+
+// retryClient retries a request which failed in a way that may succeed next
+// time: a connection which is refused, reset or times out, a 429 or a 5xx.
+// The wait before each retry is a random delay of up to BaseDelay doubled for
+// each attempt so far, capped at MaxDelay. A Retry-After from the server is
+// waited for instead, unless it is longer than MaxDelay in which case the
+// error is returned straight away.
+type retryClient struct {
+	client Client
+	config RetryConfig
+}
+
+func (r retryClient) Get(ctx context.Context, url string) (string, error) {
+	var body string
+	err := r.retry(ctx, func() (err error) {
+		body, err = r.client.Get(ctx, url)
+		return err
+	})
+	return body, err
+}
+
+// Open only retries failures up to the response headers. Once the body is
+// being streamed to the caller any error reading it is returned as it is.
+func (r retryClient) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := r.retry(ctx, func() (err error) {
+		body, err = r.client.Open(ctx, url)
+		return err
+	})
+	return body, err
+}
+
+func (r retryClient) retry(ctx context.Context, attempt func() error) error {
+	var err error
+	for count := 0; count < r.config.Attempts; count++ {
+		err = attempt()
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		if count == r.config.Attempts-1 {
+			break
+		}
+
+		delay, ok := r.delay(count, err)
+		if !ok {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+func (r retryClient) delay(attempt int, err error) (time.Duration, bool) {
+	status := &StatusError{}
+	if errors.As(err, &status) && status.RetryAfter > 0 {
+		return status.RetryAfter, status.RetryAfter <= r.config.MaxDelay
+	}
+
+	ceiling := r.config.BaseDelay << uint(attempt)
+	if ceiling > r.config.MaxDelay || ceiling <= 0 {
+		ceiling = r.config.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1)), true
+}
+
+// retryable reports whether a GET which failed with err is worth repeating:
+// a 429, a 5xx or a connection which failed, was reset or timed out. Anything
+// else, such as a certificate which cannot be verified, a malformed URL or a
+// response of the wrong type, will fail the same way next time.
+func retryable(err error) bool {
+	if IsCircuitOpen(err) || IsThrottled(err) || IsBodyTooLarge(err) {
+		return false
+	}
+
+	status := &StatusError{}
+	if errors.As(err, &status) {
+		return status.Code == http.StatusTooManyRequests || status.Code >= 500
+	}
+
+	timeout := net.Error(nil)
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
@@ -0,0 +1,234 @@
+package httpClient
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// flakyServer fails each request with the next of its failures, and succeeds
+// once they have all been used
+type flakyServer struct {
+	server     *httptest.Server
+	failures   []int
+	retryAfter string
+	requests   int
+	lock       sync.Mutex
+}
+
+func newFlakyServer(retryAfter string, failures ...int) *flakyServer {
+	flaky := &flakyServer{
+		failures:   failures,
+		retryAfter: retryAfter,
+	}
+
+	flaky.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flaky.lock.Lock()
+		defer flaky.lock.Unlock()
+
+		flaky.requests++
+		if len(flaky.failures) > 0 {
+			if len(flaky.retryAfter) > 0 {
+				w.Header().Set("Retry-After", flaky.retryAfter)
+			}
+			w.WriteHeader(flaky.failures[0])
+			flaky.failures = flaky.failures[1:]
+			return
+		}
+		w.Write([]byte("some body"))
+	}))
+	return flaky
+}
+
+func (f *flakyServer) count() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.requests
+}
+
+var _ = Describe("Retrying HTTP client", func() {
+	var (
+		config RetryConfig
+	)
+
+	BeforeEach(func() {
+		config = RetryConfig{
+			Attempts:  3,
+			BaseDelay: time.Millisecond,
+			MaxDelay:  time.Millisecond * 10,
+		}
+	})
+
+	Context("Retrying failed requests", func() {
+		When("the server fails with server errors and then recovers", func() {
+			It("should retry until the request succeeds", func() {
+				flaky := newFlakyServer("", http.StatusInternalServerError, http.StatusServiceUnavailable)
+				defer flaky.server.Close()
+
+				body, err := NewRetrying(New(Config{}), config).Get(context.Background(), flaky.server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(Equal("some body"))
+				Expect(flaky.count()).To(Equal(3))
+			})
+		})
+
+		When("the server keeps failing", func() {
+			It("should give up after the configured attempts and return the last error", func() {
+				flaky := newFlakyServer("", http.StatusBadGateway, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+				defer flaky.server.Close()
+
+				_, err := NewRetrying(New(Config{}), config).Get(context.Background(), flaky.server.URL)
+
+				status := &StatusError{}
+				Expect(errors.As(err, &status)).To(BeTrue())
+				Expect(status.Code).To(Equal(http.StatusServiceUnavailable))
+				Expect(flaky.count()).To(Equal(3))
+			})
+		})
+
+		When("the server is rate limiting", func() {
+			It("should retry", func() {
+				flaky := newFlakyServer("", http.StatusTooManyRequests)
+				defer flaky.server.Close()
+
+				_, err := NewRetrying(New(Config{}), config).Get(context.Background(), flaky.server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(flaky.count()).To(Equal(2))
+			})
+		})
+
+		When("the server gives a Retry-After", func() {
+			It("should wait for it before retrying", func() {
+				flaky := newFlakyServer("1", http.StatusServiceUnavailable)
+				defer flaky.server.Close()
+				config.MaxDelay = time.Second * 2
+
+				start := time.Now()
+				_, err := NewRetrying(New(Config{}), config).Get(context.Background(), flaky.server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+			})
+
+			It("should not retry if the Retry-After is longer than the maximum delay", func() {
+				flaky := newFlakyServer("60", http.StatusServiceUnavailable)
+				defer flaky.server.Close()
+
+				_, err := NewRetrying(New(Config{}), config).Get(context.Background(), flaky.server.URL)
+				Expect(err).To(HaveOccurred())
+				Expect(flaky.count()).To(Equal(1))
+			})
+		})
+
+		When("the server rejects the request", func() {
+			It("should not retry", func() {
+				flaky := newFlakyServer("", http.StatusNotFound)
+				defer flaky.server.Close()
+
+				_, err := NewRetrying(New(Config{}), config).Get(context.Background(), flaky.server.URL)
+
+				status := &StatusError{}
+				Expect(errors.As(err, &status)).To(BeTrue())
+				Expect(status.Code).To(Equal(http.StatusNotFound))
+				Expect(flaky.count()).To(Equal(1))
+			})
+		})
+
+		When("a circuit breaker is open", func() {
+			It("should not retry", func() {
+				attempts := 0
+				web := NewRetrying(clientFunc(func(ctx context.Context, url string) (string, error) {
+					attempts++
+					return "", &CircuitOpenError{Host: "example.org"}
+				}), config)
+
+				_, err := web.Get(context.Background(), "http://example.org")
+				Expect(IsCircuitOpen(err)).To(BeTrue())
+				Expect(attempts).To(Equal(1))
+			})
+		})
+
+		When("the server cannot be connected to", func() {
+			It("should retry and then return the connection error", func() {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).ToNot(HaveOccurred())
+				addr := listener.Addr().String()
+				listener.Close()
+
+				retries := 0
+				web := NewRetrying(clientFunc(func(ctx context.Context, url string) (string, error) {
+					retries++
+					return New(Config{}).Get(ctx, url)
+				}), config)
+
+				_, err = web.Get(context.Background(), "http://"+addr)
+				Expect(err).To(HaveOccurred())
+				Expect(retries).To(Equal(3))
+			})
+		})
+
+		When("the context is cancelled while waiting to retry", func() {
+			It("should return straight away", func() {
+				flaky := newFlakyServer("", http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+				defer flaky.server.Close()
+				config.BaseDelay = time.Hour
+				config.MaxDelay = time.Hour
+
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(time.Millisecond*20, cancel)
+
+				start := time.Now()
+				_, err := NewRetrying(New(Config{}), config).Get(ctx, flaky.server.URL)
+				Expect(err).To(HaveOccurred())
+				Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			})
+		})
+	})
+})
+
+var _ = Describe("Deciding whether to retry", func() {
+	DescribeTable("errors",
+		func(err error, expected bool) {
+			Expect(retryable(err)).To(Equal(expected))
+		},
+		Entry("a server error", &StatusError{Code: http.StatusServiceUnavailable}, true),
+		Entry("rate limiting", &StatusError{Code: http.StatusTooManyRequests}, true),
+		Entry("a location which does not exist", &StatusError{Code: http.StatusNotFound}, false),
+		Entry("a refused connection", &url.Error{Op: "Get", URL: "http://example.org", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, true),
+		Entry("a reset connection", &url.Error{Op: "Get", URL: "http://example.org", Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}, true),
+		Entry("a timeout", &url.Error{Op: "Get", URL: "http://example.org", Err: context.DeadlineExceeded}, true),
+		Entry("a body cut short", &url.Error{Op: "Get", URL: "http://example.org", Err: io.ErrUnexpectedEOF}, true),
+		Entry("an unexpected content type", &ContentTypeError{ContentType: "text/html", URL: "http://example.org"}, false),
+		Entry("a certificate which cannot be verified", &url.Error{Op: "Get", URL: "https://example.org", Err: x509.UnknownAuthorityError{}}, false),
+		Entry("a malformed URL", &url.Error{Op: "parse", URL: "://example.org", Err: errors.New("missing protocol scheme")}, false),
+	)
+
+	When("a request is made to a malformed URL", func() {
+		It("should not retry", func() {
+			_, err := New(Config{}).Get(context.Background(), "://example.org")
+			Expect(err).To(HaveOccurred())
+			Expect(retryable(err)).To(BeFalse())
+		})
+	})
+})
+
+type clientFunc func(ctx context.Context, url string) (string, error)
+
+func (f clientFunc) Get(ctx context.Context, url string) (string, error) {
+	return f(ctx, url)
+}
+
+func (f clientFunc) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	return stringBody(f(ctx, url))
+}
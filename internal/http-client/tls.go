@@ -0,0 +1,86 @@
+package httpClient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	ErrorReadCA      = "Could not read the CA certificate %s: %s"
+	ErrorNoCA        = "No PEM certificates were found in %s"
+	ErrorClientCert  = "Could not load the client certificate: %s"
+	ErrorCertWithKey = "A client certificate and its key must be given together"
+	ErrorTLSVersion  = "Unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3"
+
+	DefaultTLSVersion = "1.2"
+)
+
+var (
+	tlsVersions = map[string]uint16{
+		"1.0": tls.VersionTLS10,
+		"1.1": tls.VersionTLS11,
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+)
+
+// TLSConfig gives the PEM files of any CAs to trust alongside the system
+// roots, a client certificate and key to present to servers which ask for
+// one, and the minimum TLS version, eg. "1.2"
+type TLSConfig struct {
+	CAFiles           []string
+	CertFile, KeyFile string
+	MinVersion        string
+}
+
+// LoadTLS reads the files named by the config into a tls.Config for New
+func LoadTLS(config TLSConfig) (*tls.Config, error) {
+	if len(config.MinVersion) < 1 {
+		config.MinVersion = DefaultTLSVersion
+	}
+
+	version, known := tlsVersions[strings.TrimSpace(config.MinVersion)]
+	if !known {
+		return nil, fmt.Errorf(ErrorTLSVersion, config.MinVersion)
+	}
+
+	loaded := &tls.Config{
+		MinVersion: version,
+	}
+
+	if len(config.CAFiles) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+
+		for _, path := range config.CAFiles {
+			pem, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf(ErrorReadCA, path, err.Error())
+			}
+
+			if !roots.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf(ErrorNoCA, path)
+			}
+		}
+		loaded.RootCAs = roots
+	}
+
+	if len(config.CertFile) > 0 != (len(config.KeyFile) > 0) {
+		return nil, errors.New(ErrorCertWithKey)
+	}
+
+	if len(config.CertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf(ErrorClientCert, err.Error())
+		}
+		loaded.Certificates = []tls.Certificate{cert}
+	}
+	return loaded, nil
+}
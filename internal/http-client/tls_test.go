@@ -0,0 +1,183 @@
+package httpClient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// writeClientCert creates a self-signed client certificate, returning the
+// paths of its PEM certificate and key files along with the certificate
+func writeClientCert(dir string) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tech-test client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+	Expect(ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).To(Succeed())
+	return certPath, keyPath, cert
+}
+
+var _ = Describe("TLS configuration", func() {
+	var (
+		dir    string
+		server *httptest.Server
+		caFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "tls")
+		Expect(err).ToNot(HaveOccurred())
+
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("some body"))
+		}))
+		server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	})
+
+	JustBeforeEach(func() {
+		server.StartTLS()
+		caFile = filepath.Join(dir, "ca.pem")
+		Expect(ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	When("the server's CA is not trusted", func() {
+		It("should refuse the connection", func() {
+			loaded, err := LoadTLS(TLSConfig{})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = New(Config{TLS: loaded}).Get(context.Background(), server.URL)
+			unknown := x509.UnknownAuthorityError{}
+			Expect(errors.As(err, &unknown)).To(BeTrue())
+		})
+	})
+
+	When("the server's CA is given", func() {
+		It("should trust the server", func() {
+			loaded, err := LoadTLS(TLSConfig{CAFiles: []string{caFile}})
+			Expect(err).ToNot(HaveOccurred())
+
+			body, err := New(Config{TLS: loaded}).Get(context.Background(), server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(Equal("some body"))
+		})
+	})
+
+	When("the minimum TLS version is higher than the server supports", func() {
+		It("should refuse the connection", func() {
+			loaded, err := LoadTLS(TLSConfig{CAFiles: []string{caFile}, MinVersion: "1.3"})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = New(Config{TLS: loaded}).Get(context.Background(), server.URL)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Presenting a client certificate", func() {
+		var (
+			certFile, keyFile string
+		)
+
+		BeforeEach(func() {
+			var cert *x509.Certificate
+			certFile, keyFile, cert = writeClientCert(dir)
+
+			clients := x509.NewCertPool()
+			clients.AddCert(cert)
+			server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+			server.TLS.ClientCAs = clients
+		})
+
+		When("the server requires one and it is configured", func() {
+			It("should be accepted", func() {
+				loaded, err := LoadTLS(TLSConfig{CAFiles: []string{caFile}, CertFile: certFile, KeyFile: keyFile})
+				Expect(err).ToNot(HaveOccurred())
+
+				body, err := New(Config{TLS: loaded}).Get(context.Background(), server.URL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(Equal("some body"))
+			})
+		})
+
+		When("the server requires one and none is configured", func() {
+			It("should be refused", func() {
+				loaded, err := LoadTLS(TLSConfig{CAFiles: []string{caFile}})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = New(Config{TLS: loaded}).Get(context.Background(), server.URL)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Loading the configuration", func() {
+		It("should default to a minimum of TLS 1.2", func() {
+			loaded, err := LoadTLS(TLSConfig{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+		})
+
+		It("should reject an unknown TLS version", func() {
+			_, err := LoadTLS(TLSConfig{MinVersion: "2.0"})
+			Expect(err).To(MatchError(`Unknown TLS version "2.0", expected 1.0, 1.1, 1.2 or 1.3`))
+		})
+
+		It("should reject a CA file with no certificates", func() {
+			empty := filepath.Join(dir, "empty.pem")
+			Expect(ioutil.WriteFile(empty, []byte("nothing here"), 0600)).To(Succeed())
+
+			_, err := LoadTLS(TLSConfig{CAFiles: []string{empty}})
+			Expect(err).To(MatchError("No PEM certificates were found in " + empty))
+		})
+
+		It("should reject a CA file which does not exist", func() {
+			_, err := LoadTLS(TLSConfig{CAFiles: []string{filepath.Join(dir, "missing.pem")}})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject a client certificate without its key", func() {
+			_, err := LoadTLS(TLSConfig{CertFile: caFile})
+			Expect(err).To(MatchError(ErrorCertWithKey))
+		})
+	})
+})
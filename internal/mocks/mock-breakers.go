@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jddcode/tech-test-ennismore/internal/handler-health (interfaces: Breakers)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
+)
+
+// MockBreakers is a mock of Breakers interface.
+type MockBreakers struct {
+	ctrl     *gomock.Controller
+	recorder *MockBreakersMockRecorder
+}
+
+// MockBreakersMockRecorder is the mock recorder for MockBreakers.
+type MockBreakersMockRecorder struct {
+	mock *MockBreakers
+}
+
+// NewMockBreakers creates a new mock instance.
+func NewMockBreakers(ctrl *gomock.Controller) *MockBreakers {
+	mock := &MockBreakers{ctrl: ctrl}
+	mock.recorder = &MockBreakersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBreakers) EXPECT() *MockBreakersMockRecorder {
+	return m.recorder
+}
+
+// States mocks base method.
+func (m *MockBreakers) States() []httpClient.BreakerStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "States")
+	ret0, _ := ret[0].([]httpClient.BreakerStatus)
+	return ret0
+}
+
+// States indicates an expected call of States.
+func (mr *MockBreakersMockRecorder) States() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "States", reflect.TypeOf((*MockBreakers)(nil).States))
+}
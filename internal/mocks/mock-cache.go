@@ -6,8 +6,10 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	cache "github.com/jddcode/tech-test-ennismore/internal/handler-weather/cache"
 	structs "github.com/jddcode/tech-test-ennismore/internal/handler-weather/structs"
 )
 
@@ -34,6 +36,30 @@ func (m *MockCache) EXPECT() *MockCacheMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockCache) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockCacheMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockCache)(nil).Close))
+}
+
+// Delete mocks base method.
+func (m *MockCache) Delete(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", arg0)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCacheMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCache)(nil).Delete), arg0)
+}
+
 // Get mocks base method.
 func (m *MockCache) Get(arg0 string) ([]structs.ResultForecast, error) {
 	m.ctrl.T.Helper()
@@ -49,14 +75,84 @@ func (mr *MockCacheMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), arg0)
 }
 
+// GetStale mocks base method.
+func (m *MockCache) GetStale(arg0 string) ([]structs.ResultForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStale", arg0)
+	ret0, _ := ret[0].([]structs.ResultForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStale indicates an expected call of GetStale.
+func (mr *MockCacheMockRecorder) GetStale(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStale", reflect.TypeOf((*MockCache)(nil).GetStale), arg0)
+}
+
+// List mocks base method.
+func (m *MockCache) List() []cache.Entry {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]cache.Entry)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockCacheMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCache)(nil).List))
+}
+
+// Lookup mocks base method.
+func (m *MockCache) Lookup(arg0 string) (cache.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", arg0)
+	ret0, _ := ret[0].(cache.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockCacheMockRecorder) Lookup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockCache)(nil).Lookup), arg0)
+}
+
+// Purge mocks base method.
+func (m *MockCache) Purge() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Purge")
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockCacheMockRecorder) Purge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockCache)(nil).Purge))
+}
+
+// Stats mocks base method.
+func (m *MockCache) Stats() cache.Stats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(cache.Stats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCacheMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCache)(nil).Stats))
+}
+
 // Store mocks base method.
-func (m *MockCache) Store(arg0 string, arg1 []structs.ResultForecast) {
+func (m *MockCache) Store(arg0 string, arg1 []structs.ResultForecast, arg2 time.Time) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Store", arg0, arg1)
+	m.ctrl.Call(m, "Store", arg0, arg1, arg2)
 }
 
 // Store indicates an expected call of Store.
-func (mr *MockCacheMockRecorder) Store(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCacheMockRecorder) Store(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockCache)(nil).Store), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockCache)(nil).Store), arg0, arg1, arg2)
 }
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,7 +36,7 @@ func (m *MockFinder) EXPECT() *MockFinderMockRecorder {
 }
 
 // Find mocks base method.
-func (m *MockFinder) Find(arg0, arg1 string) (structs.CoOrdinates, error) {
+func (m *MockFinder) Find(arg0 context.Context, arg1 structs.Place) (structs.CoOrdinates, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Find", arg0, arg1)
 	ret0, _ := ret[0].(structs.CoOrdinates)
@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jddcode/tech-test-ennismore/internal/handler-admin (interfaces: Warmer)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWarmer is a mock of Warmer interface.
+type MockWarmer struct {
+	ctrl     *gomock.Controller
+	recorder *MockWarmerMockRecorder
+}
+
+// MockWarmerMockRecorder is the mock recorder for MockWarmer.
+type MockWarmerMockRecorder struct {
+	mock *MockWarmer
+}
+
+// NewMockWarmer creates a new mock instance.
+func NewMockWarmer(ctrl *gomock.Controller) *MockWarmer {
+	mock := &MockWarmer{ctrl: ctrl}
+	mock.recorder = &MockWarmerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWarmer) EXPECT() *MockWarmerMockRecorder {
+	return m.recorder
+}
+
+// Key mocks base method.
+func (m *MockWarmer) Key(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Key", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Key indicates an expected call of Key.
+func (mr *MockWarmerMockRecorder) Key(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Key", reflect.TypeOf((*MockWarmer)(nil).Key), arg0)
+}
+
+// Warm mocks base method.
+func (m *MockWarmer) Warm(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Warm", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Warm indicates an expected call of Warm.
+func (mr *MockWarmerMockRecorder) Warm(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warm", reflect.TypeOf((*MockWarmer)(nil).Warm), arg0, arg1)
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,16 +36,46 @@ func (m *MockWeatherFetcher) EXPECT() *MockWeatherFetcherMockRecorder {
 }
 
 // Fetch mocks base method.
-func (m *MockWeatherFetcher) Fetch(arg0 structs.CoOrdinates) ([]structs.Weather, error) {
+func (m *MockWeatherFetcher) Fetch(arg0 context.Context, arg1 structs.CoOrdinates) ([]structs.Weather, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Fetch", arg0)
+	ret := m.ctrl.Call(m, "Fetch", arg0, arg1)
 	ret0, _ := ret[0].([]structs.Weather)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Fetch indicates an expected call of Fetch.
-func (mr *MockWeatherFetcherMockRecorder) Fetch(arg0 interface{}) *gomock.Call {
+func (mr *MockWeatherFetcherMockRecorder) Fetch(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockWeatherFetcher)(nil).Fetch), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockWeatherFetcher)(nil).Fetch), arg0, arg1)
+}
+
+// Forecast mocks base method.
+func (m *MockWeatherFetcher) Forecast(arg0 context.Context, arg1 structs.Grid) ([]structs.Weather, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Forecast", arg0, arg1)
+	ret0, _ := ret[0].([]structs.Weather)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Forecast indicates an expected call of Forecast.
+func (mr *MockWeatherFetcherMockRecorder) Forecast(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Forecast", reflect.TypeOf((*MockWeatherFetcher)(nil).Forecast), arg0, arg1)
+}
+
+// Locate mocks base method.
+func (m *MockWeatherFetcher) Locate(arg0 context.Context, arg1 structs.CoOrdinates) (structs.Grid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Locate", arg0, arg1)
+	ret0, _ := ret[0].(structs.Grid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Locate indicates an expected call of Locate.
+func (mr *MockWeatherFetcherMockRecorder) Locate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Locate", reflect.TypeOf((*MockWeatherFetcher)(nil).Locate), arg0, arg1)
 }
@@ -0,0 +1,246 @@
+package place
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrorCountry   = "Unrecognised ISO 3166-1 country code: %s"
+	ErrorState     = "Unrecognised US state: %s"
+	ErrorZip       = "Unrecognised US ZIP code: %s"
+	ErrorLatitude  = "Unrecognised latitude, expected -90 to 90: %s"
+	ErrorLongitude = "Unrecognised longitude, expected -180 to 180: %s"
+	ErrorPositions = "Each latitude must be given with a longitude"
+	ErrorPlace     = "Unrecognised place, expected city|state|country: %s"
+	ErrorBareCode  = "%s is a state or country code rather than a city, give it after the city and a bar, eg. %s|%s"
+
+	DefaultCountry = "us"
+)
+
+var (
+	zipCode = regexp.MustCompile(`^([0-9]{5})(-[0-9]{4})?$`)
+)
+
+// Query is one place asked for by the guest, with the text it was asked for
+// by. A query for co-ordinates has a Position and no Place, since it needs no
+// geocoding.
+type Query struct {
+	Text     string
+	Place    structs.Place
+	Position *structs.CoOrdinates
+}
+
+// Key identifies what the query asks for: its place's key, or its position
+// to the precision the NWS uses, eg. "41.87000,-87.62000"
+func (q Query) Key() string {
+	if q.Position != nil {
+		return fmt.Sprintf("%.5f,%.5f", q.Position.Latitude, q.Position.Longitude)
+	}
+	return q.Place.Key()
+}
+
+// String describes what the query asks for, for people
+func (q Query) String() string {
+	if q.Position != nil {
+		return q.Key()
+	}
+	return q.Place.String()
+}
+
+// Country reads an ISO 3166-1 alpha-2 or alpha-3 country code in any case,
+// returning its lower case alpha-2 code
+func Country(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if _, exists := countries[code]; exists {
+		return code, nil
+	}
+
+	for alpha2, alpha3 := range countries {
+		if alpha3 == code {
+			return alpha2, nil
+		}
+	}
+	return "", fmt.Errorf(ErrorCountry, code)
+}
+
+// State reads a state or region of the country. The US states, the District
+// of Columbia and the territories are recognised by their USPS code or their
+// name and returned by name, so either way of writing one finds the same
+// place. Regions of other countries are passed on as they are, in lower case.
+func State(state, country string) (string, error) {
+	state = strings.Join(strings.Fields(strings.ToLower(state)), " ")
+	if len(state) < 1 || country != DefaultCountry {
+		return state, nil
+	}
+
+	if name, ok := usState(state); ok {
+		return name, nil
+	}
+	return "", fmt.Errorf(ErrorState, state)
+}
+
+func usState(state string) (string, bool) {
+	if name, exists := usStates[state]; exists {
+		return name, true
+	}
+
+	for _, name := range usStates {
+		if name == state {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func isCountry(text string) bool {
+	_, err := Country(text)
+	return err == nil
+}
+
+// isCode reports whether text is a country code, or a US state code where
+// places are in the US by default
+func isCode(text, country string) bool {
+	code := strings.ToLower(text)
+	if _, exists := usStates[code]; exists && country == DefaultCountry {
+		return true
+	}
+	return isCountry(code)
+}
+
+func isUSState(text string) bool {
+	_, ok := usState(strings.Join(strings.Fields(strings.ToLower(text)), " "))
+	return ok
+}
+
+// ParseList reads a comma delimited list of places, each a city optionally
+// followed by its state and country separated by bars, eg.
+// "Springfield|IL,London||GB,chicago", or a US ZIP code where the default
+// country is the US. A place with one bar gives a state where the default
+// country is the US and the text is a US state, so a code which could be a
+// US state or a country, such as IN, is read as the state, and otherwise a
+// country if it is one. Places which give no state or country take them from
+// defaults, except that giving a country drops the default state. An entry
+// which is only a US state or country code, as in "Springfield, IL", is
+// rejected rather than read as a city, as it was almost certainly meant to
+// narrow down the city before it.
+func ParseList(list string, defaults structs.Place) ([]Query, error) {
+	queries := make([]Query, 0)
+	previous := "city"
+	for _, entry := range strings.Split(list, ",") {
+		parts := strings.Split(entry, "|")
+		for index := range parts {
+			parts[index] = strings.TrimSpace(parts[index])
+		}
+
+		text := strings.Join(parts, "|")
+		if len(text) < 1 {
+			continue
+		}
+
+		if len(parts[0]) < 1 || len(parts) > 3 {
+			return nil, fmt.Errorf(ErrorPlace, text)
+		}
+
+		if len(parts) == 1 && isCode(text, defaults.Country) {
+			return nil, fmt.Errorf(ErrorBareCode, text, previous, text)
+		}
+		previous = parts[0]
+
+		place := defaults
+		place.City = parts[0]
+		switch {
+		case len(parts) == 3 && len(parts[2]) > 0:
+			place.State = parts[1]
+			place.Country = parts[2]
+		case len(parts) == 3:
+			place.State = parts[1]
+		case len(parts) == 2 && defaults.Country == DefaultCountry && isUSState(parts[1]):
+			place.State = parts[1]
+		case len(parts) == 2 && isCountry(parts[1]):
+			place.State = ""
+			place.Country = parts[1]
+		case len(parts) == 2:
+			place.State = parts[1]
+		case defaults.Country == DefaultCountry && zipCode.MatchString(place.City):
+			place = zip(place.City)
+		}
+
+		var err error
+		if place.Country, err = Country(place.Country); err != nil {
+			return nil, err
+		}
+
+		if place.State, err = State(place.State, place.Country); err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, Query{
+			Text:  text,
+			Place: place,
+		})
+	}
+	return queries, nil
+}
+
+// ParseZips reads a comma delimited list of US ZIP codes, which may be given
+// as ZIP+4, eg. "60601,10001-1234"
+func ParseZips(list string) ([]Query, error) {
+	queries := make([]Query, 0)
+	for _, item := range strings.Split(list, ",") {
+		code := strings.TrimSpace(item)
+		if len(code) < 1 {
+			continue
+		}
+
+		if !zipCode.MatchString(code) {
+			return nil, fmt.Errorf(ErrorZip, code)
+		}
+
+		queries = append(queries, Query{
+			Text:  code,
+			Place: zip(code),
+		})
+	}
+	return queries, nil
+}
+
+// zip is the place for a ZIP code, which is only ever looked up by its first
+// five digits
+func zip(code string) structs.Place {
+	return structs.Place{
+		Postcode: zipCode.FindStringSubmatch(code)[1],
+		Country:  DefaultCountry,
+	}
+}
+
+// ParsePositions reads pairs of co-ordinates, the first latitude with the
+// first longitude and so on, checking each is in range
+func ParsePositions(latitudes, longitudes []string) ([]Query, error) {
+	if len(latitudes) != len(longitudes) {
+		return nil, errors.New(ErrorPositions)
+	}
+
+	queries := make([]Query, 0, len(latitudes))
+	for index := range latitudes {
+		lat, err := strconv.ParseFloat(strings.TrimSpace(latitudes[index]), 64)
+		if err != nil || !(lat >= -90 && lat <= 90) {
+			return nil, fmt.Errorf(ErrorLatitude, latitudes[index])
+		}
+
+		lon, err := strconv.ParseFloat(strings.TrimSpace(longitudes[index]), 64)
+		if err != nil || !(lon >= -180 && lon <= 180) {
+			return nil, fmt.Errorf(ErrorLongitude, longitudes[index])
+		}
+
+		queries = append(queries, Query{
+			Text:     strings.TrimSpace(latitudes[index]) + "," + strings.TrimSpace(longitudes[index]),
+			Position: &structs.CoOrdinates{Latitude: lat, Longitude: lon},
+		})
+	}
+	return queries, nil
+}
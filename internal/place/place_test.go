@@ -0,0 +1,212 @@
+package place
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Places", func() {
+	var (
+		defaults structs.Place
+	)
+
+	BeforeEach(func() {
+		defaults = structs.Place{Country: DefaultCountry}
+	})
+
+	Context("Reading a country code", func() {
+		DescribeTable("recognised codes",
+			func(code, expected string) {
+				country, err := Country(code)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(country).To(Equal(expected))
+			},
+			Entry("alpha-2", "gb", "gb"),
+			Entry("upper case alpha-2", "GB", "gb"),
+			Entry("alpha-3", "USA", "us"),
+			Entry("surrounding whitespace", " fr ", "fr"),
+		)
+
+		When("the code is not an ISO 3166-1 code", func() {
+			It("should return an error", func() {
+				_, err := Country("xx")
+				Expect(err).To(Equal(fmt.Errorf(ErrorCountry, "xx")))
+			})
+		})
+	})
+
+	Context("Reading a state", func() {
+		DescribeTable("recognised states",
+			func(state, country, expected string) {
+				name, err := State(state, country)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(name).To(Equal(expected))
+			},
+			Entry("a US state code", "IL", "us", "illinois"),
+			Entry("a US state name", "New  York", "us", "new york"),
+			Entry("no state", "", "us", ""),
+			Entry("a region of another country", "Île-de-France", "fr", "île-de-france"),
+		)
+
+		When("a US state is not recognised", func() {
+			It("should return an error", func() {
+				_, err := State("Narnia", "us")
+				Expect(err).To(Equal(fmt.Errorf(ErrorState, "narnia")))
+			})
+		})
+	})
+
+	Context("Parsing a list of places", func() {
+		DescribeTable("lists of places",
+			func(list string, expected []Query) {
+				queries, err := ParseList(list, defaults)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(queries).To(Equal(expected))
+			},
+			Entry("cities on their own", "chicago,Boston", []Query{
+				{Text: "chicago", Place: structs.Place{City: "chicago", Country: "us"}},
+				{Text: "Boston", Place: structs.Place{City: "Boston", Country: "us"}},
+			}),
+			Entry("cities which are also states", "chicago,new york", []Query{
+				{Text: "chicago", Place: structs.Place{City: "chicago", Country: "us"}},
+				{Text: "new york", Place: structs.Place{City: "new york", Country: "us"}},
+			}),
+			Entry("cities which are also state names", "portland,washington", []Query{
+				{Text: "portland", Place: structs.Place{City: "portland", Country: "us"}},
+				{Text: "washington", Place: structs.Place{City: "washington", Country: "us"}},
+			}),
+			Entry("a city and state", "Springfield | IL,chicago", []Query{
+				{Text: "Springfield|IL", Place: structs.Place{City: "Springfield", State: "illinois", Country: "us"}},
+				{Text: "chicago", Place: structs.Place{City: "chicago", Country: "us"}},
+			}),
+			Entry("a city, state and country", "springfield|illinois|usa", []Query{
+				{Text: "springfield|illinois|usa", Place: structs.Place{City: "springfield", State: "illinois", Country: "us"}},
+			}),
+			Entry("a city and country", "london|gb,chicago", []Query{
+				{Text: "london|gb", Place: structs.Place{City: "london", Country: "gb"}},
+				{Text: "chicago", Place: structs.Place{City: "chicago", Country: "us"}},
+			}),
+			Entry("a city and country with no state", "paris||IN", []Query{
+				{Text: "paris||IN", Place: structs.Place{City: "paris", Country: "in"}},
+			}),
+			Entry("a city, region and country", "london|england|gb", []Query{
+				{Text: "london|england|gb", Place: structs.Place{City: "london", State: "england", Country: "gb"}},
+			}),
+			Entry("a code which is both a state and a country", "indianapolis|IN", []Query{
+				{Text: "indianapolis|IN", Place: structs.Place{City: "indianapolis", State: "indiana", Country: "us"}},
+			}),
+			Entry("empty entries", ",chicago,,", []Query{
+				{Text: "chicago", Place: structs.Place{City: "chicago", Country: "us"}},
+			}),
+			Entry("a ZIP code", "60601,chicago", []Query{
+				{Text: "60601", Place: structs.Place{Postcode: "60601", Country: "us"}},
+				{Text: "chicago", Place: structs.Place{City: "chicago", Country: "us"}},
+			}),
+		)
+
+		DescribeTable("places which cannot be read",
+			func(list string, expected error) {
+				_, err := ParseList(list, defaults)
+				Expect(err).To(Equal(expected))
+			},
+			Entry("a state with no city", "|IL", fmt.Errorf(ErrorPlace, "|IL")),
+			Entry("too many parts", "springfield|il|us|earth", fmt.Errorf(ErrorPlace, "springfield|il|us|earth")),
+			Entry("an unrecognised US state", "springfield|narnia", fmt.Errorf(ErrorState, "narnia")),
+			Entry("a US state code on its own", "Springfield, IL", fmt.Errorf(ErrorBareCode, "IL", "Springfield", "IL")),
+			Entry("a state and country code on their own", "chicago,IL,US", fmt.Errorf(ErrorBareCode, "IL", "chicago", "IL")),
+			Entry("a code before any city", "gb,london", fmt.Errorf(ErrorBareCode, "gb", "city", "gb")),
+		)
+
+		When("the defaults give another country", func() {
+			It("should not look for ZIP codes", func() {
+				queries, err := ParseList("75001", structs.Place{Country: "fr"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(queries).To(Equal([]Query{
+					{Text: "75001", Place: structs.Place{City: "75001", Country: "fr"}},
+				}))
+			})
+
+			It("should read a place which is not a country as the region", func() {
+				queries, err := ParseList("perth|wa,perth", structs.Place{Country: "au"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(queries).To(Equal([]Query{
+					{Text: "perth|wa", Place: structs.Place{City: "perth", State: "wa", Country: "au"}},
+					{Text: "perth", Place: structs.Place{City: "perth", Country: "au"}},
+				}))
+			})
+		})
+
+		When("the defaults give a state", func() {
+			It("should use it unless the place gives a country", func() {
+				queries, err := ParseList("paris|fr,springfield", structs.Place{State: "illinois", Country: "us"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(queries).To(Equal([]Query{
+					{Text: "paris|fr", Place: structs.Place{City: "paris", Country: "fr"}},
+					{Text: "springfield", Place: structs.Place{City: "springfield", State: "illinois", Country: "us"}},
+				}))
+			})
+		})
+	})
+
+	Context("Parsing a list of ZIP codes", func() {
+		When("the codes are valid", func() {
+			It("should return a place for the first five digits of each", func() {
+				queries, err := ParseZips("60601, 10001-1234,")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(queries).To(Equal([]Query{
+					{Text: "60601", Place: structs.Place{Postcode: "60601", Country: "us"}},
+					{Text: "10001-1234", Place: structs.Place{Postcode: "10001", Country: "us"}},
+				}))
+				Expect(queries[1].Key()).To(Equal("10001,us"))
+			})
+		})
+
+		When("a code is not a ZIP code", func() {
+			It("should return an error", func() {
+				_, err := ParseZips("60601,6060")
+				Expect(err).To(Equal(fmt.Errorf(ErrorZip, "6060")))
+			})
+		})
+	})
+
+	Context("Parsing co-ordinates", func() {
+		When("each latitude has a longitude", func() {
+			It("should return a position for each pair", func() {
+				queries, err := ParsePositions([]string{"41.87", " -33.9"}, []string{"-87.62", "151.2"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(queries).To(Equal([]Query{
+					{Text: "41.87,-87.62", Position: &structs.CoOrdinates{Latitude: 41.87, Longitude: -87.62}},
+					{Text: "-33.9,151.2", Position: &structs.CoOrdinates{Latitude: -33.9, Longitude: 151.2}},
+				}))
+				Expect(queries[0].Key()).To(Equal("41.87000,-87.62000"))
+			})
+		})
+
+		When("the latitudes and longitudes do not pair up", func() {
+			It("should return an error", func() {
+				_, err := ParsePositions([]string{"41.87", "42"}, []string{"-87.62"})
+				Expect(err).To(Equal(errors.New(ErrorPositions)))
+			})
+		})
+
+		DescribeTable("co-ordinates out of range",
+			func(lat, lon, expected string) {
+				_, err := ParsePositions([]string{lat}, []string{lon})
+				Expect(err).To(MatchError(expected))
+			},
+			Entry("a latitude beyond a pole", "91", "0", fmt.Sprintf(ErrorLatitude, "91")),
+			Entry("a latitude which is not a number", "NaN", "0", fmt.Sprintf(ErrorLatitude, "NaN")),
+			Entry("a longitude beyond the antimeridian", "0", "-180.5", fmt.Sprintf(ErrorLongitude, "-180.5")),
+		)
+	})
+})
@@ -0,0 +1,19 @@
+package place
+
+// usStates maps the USPS code of each US state, the District of Columbia and
+// the inhabited territories to its name
+var usStates = map[string]string{
+	"al": "alabama", "ak": "alaska", "az": "arizona", "ar": "arkansas", "ca": "california",
+	"co": "colorado", "ct": "connecticut", "de": "delaware", "fl": "florida", "ga": "georgia",
+	"hi": "hawaii", "id": "idaho", "il": "illinois", "in": "indiana", "ia": "iowa",
+	"ks": "kansas", "ky": "kentucky", "la": "louisiana", "me": "maine", "md": "maryland",
+	"ma": "massachusetts", "mi": "michigan", "mn": "minnesota", "ms": "mississippi",
+	"mo": "missouri", "mt": "montana", "ne": "nebraska", "nv": "nevada", "nh": "new hampshire",
+	"nj": "new jersey", "nm": "new mexico", "ny": "new york", "nc": "north carolina",
+	"nd": "north dakota", "oh": "ohio", "ok": "oklahoma", "or": "oregon", "pa": "pennsylvania",
+	"ri": "rhode island", "sc": "south carolina", "sd": "south dakota", "tn": "tennessee",
+	"tx": "texas", "ut": "utah", "vt": "vermont", "va": "virginia", "wa": "washington",
+	"wv": "west virginia", "wi": "wisconsin", "wy": "wyoming", "dc": "district of columbia",
+	"as": "american samoa", "gu": "guam", "mp": "northern mariana islands", "pr": "puerto rico",
+	"vi": "united states virgin islands",
+}
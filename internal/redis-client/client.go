@@ -0,0 +1,232 @@
+package redisClient
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	ErrorNotFound     = "key not found"
+	ErrorConnect      = "Could not connect to redis: %s"
+	ErrorCommand      = "Redis command failed: %s"
+	ErrorReply        = "Unexpected reply from redis: %s"
+	ErrorClosed       = "The redis client has been closed"
+	ErrorAuthenticate = "Could not authenticate with redis: %s"
+	ErrorSelectDB     = "Could not select redis database %d: %s"
+)
+
+//go:generate mockgen -destination=../mocks/mock-redis-client.go -package=mocks -mock_names=Client=MockRedisClient . Client
+type Client interface {
+	Get(key string) (string, error)
+	Set(key, value string, ttl time.Duration) error
+	Del(keys ...string) error
+	Scan(pattern string) ([]string, error)
+	Close() error
+}
+
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+	Timeout  time.Duration
+	MaxIdle  int
+}
+
+// redisError is an error reply sent by the server, as opposed to a problem
+// with the connection, so the connection it arrived on can be reused
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+type conn struct {
+	net    net.Conn
+	reader *bufio.Reader
+}
+
+// client speaks RESP to a redis compatible server over a small pool of
+// connections. Connections are dialled when none are idle and any connection
+// which has a network or protocol error is thrown away.
+type client struct {
+	config Config
+	idle   []*conn
+	closed bool
+	lock   sync.Mutex
+}
+
+func (c *client) Get(key string) (string, error) {
+	reply, err := c.do("GET", key)
+	if err != nil {
+		return "", err
+	}
+
+	if reply == nil {
+		return "", errors.New(ErrorNotFound)
+	}
+
+	value, ok := reply.(string)
+	if !ok {
+		return "", fmt.Errorf(ErrorReply, "GET did not return a string")
+	}
+	return value, nil
+}
+
+// Set stores the value, expiring it after the TTL rounded up to the next
+// millisecond, or never if the TTL is not positive
+func (c *client) Set(key, value string, ttl time.Duration) error {
+	args := []string{"SET", key, value}
+	if ttl > 0 {
+		milliseconds := (ttl + time.Millisecond - 1) / time.Millisecond
+		args = append(args, "PX", strconv.FormatInt(int64(milliseconds), 10))
+	}
+
+	_, err := c.do(args...)
+	return err
+}
+
+func (c *client) Del(keys ...string) error {
+	if len(keys) < 1 {
+		return nil
+	}
+
+	_, err := c.do(append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Scan returns every key matching the pattern, following the SCAN cursor
+// until the server reports it has finished
+func (c *client) Scan(pattern string) ([]string, error) {
+	keys := make([]string, 0)
+	cursor := "0"
+	for {
+		reply, err := c.do("SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, fmt.Errorf(ErrorReply, "SCAN did not return a cursor and keys")
+		}
+
+		cursor, ok = parts[0].(string)
+		if !ok {
+			return nil, fmt.Errorf(ErrorReply, "SCAN did not return a cursor")
+		}
+
+		batch, _ := parts[1].([]interface{})
+		for _, key := range batch {
+			if myKey, ok := key.(string); ok {
+				keys = append(keys, myKey)
+			}
+		}
+
+		if cursor == "0" {
+			return keys, nil
+		}
+	}
+}
+
+func (c *client) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.closed = true
+	for _, idle := range c.idle {
+		idle.net.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+func (c *client) do(args ...string) (interface{}, error) {
+	myConn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := myConn.command(c.config.Timeout, args...)
+	if _, isRedisError := err.(redisError); err != nil && !isRedisError {
+		myConn.net.Close()
+		return nil, fmt.Errorf(ErrorCommand, err.Error())
+	}
+
+	c.put(myConn)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorCommand, err.Error())
+	}
+	return reply, nil
+}
+
+func (c *client) get() (*conn, error) {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil, errors.New(ErrorClosed)
+	}
+
+	if len(c.idle) > 0 {
+		myConn := c.idle[len(c.idle)-1]
+		c.idle = c.idle[:len(c.idle)-1]
+		c.lock.Unlock()
+		return myConn, nil
+	}
+	c.lock.Unlock()
+
+	return c.dial()
+}
+
+func (c *client) put(myConn *conn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed || len(c.idle) >= c.config.MaxIdle {
+		myConn.net.Close()
+		return
+	}
+	c.idle = append(c.idle, myConn)
+}
+
+func (c *client) dial() (*conn, error) {
+	netConn, err := net.DialTimeout("tcp", c.config.Addr, c.config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorConnect, err.Error())
+	}
+
+	myConn := &conn{
+		net:    netConn,
+		reader: bufio.NewReader(netConn),
+	}
+
+	if len(c.config.Password) > 0 {
+		if _, err := myConn.command(c.config.Timeout, "AUTH", c.config.Password); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf(ErrorAuthenticate, err.Error())
+		}
+	}
+
+	if c.config.DB > 0 {
+		if _, err := myConn.command(c.config.Timeout, "SELECT", strconv.Itoa(c.config.DB)); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf(ErrorSelectDB, c.config.DB, err.Error())
+		}
+	}
+	return myConn, nil
+}
+
+func (c *conn) command(timeout time.Duration, args ...string) (interface{}, error) {
+	if timeout > 0 {
+		c.net.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if _, err := c.net.Write(EncodeCommand(args...)); err != nil {
+		return nil, err
+	}
+	return ReadReply(c.reader)
+}
@@ -0,0 +1,125 @@
+package redisClient_test
+
+import (
+	"errors"
+	redisClient "github.com/jddcode/tech-test-ennismore/internal/redis-client"
+	redisTest "github.com/jddcode/tech-test-ennismore/internal/redis-client/redis-test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Redis client", func() {
+	var (
+		server *redisTest.Server
+		client redisClient.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+		server, err = redisTest.NewServer()
+		Expect(err).ToNot(HaveOccurred())
+		client = redisClient.New(redisClient.Config{Addr: server.Addr()})
+	})
+
+	AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	Context("Reading and writing keys", func() {
+		When("a key is set and read back", func() {
+			It("should return the value", func() {
+				Expect(client.Set("key", "some\r\nvalue", 0)).To(Succeed())
+
+				value, err := client.Get("key")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal("some\r\nvalue"))
+			})
+		})
+
+		When("a key does not exist", func() {
+			It("should return a not found error", func() {
+				_, err := client.Get("missing")
+				Expect(err).To(Equal(errors.New(redisClient.ErrorNotFound)))
+			})
+		})
+
+		When("a key is set with a TTL", func() {
+			It("should be given an expiry by the server", func() {
+				Expect(client.Set("key", "value", time.Minute)).To(Succeed())
+				Expect(server.TTL("key")).To(BeNumerically("~", time.Minute, time.Second))
+			})
+
+			It("should round a TTL under a millisecond up rather than send zero", func() {
+				Expect(client.Set("key", "value", time.Microsecond*500)).To(Succeed())
+			})
+		})
+
+		When("a key is deleted", func() {
+			It("should no longer exist", func() {
+				Expect(client.Set("key", "value", 0)).To(Succeed())
+				Expect(client.Del("key")).To(Succeed())
+
+				_, err := client.Get("key")
+				Expect(err).To(Equal(errors.New(redisClient.ErrorNotFound)))
+			})
+		})
+
+		When("keys are scanned with a pattern", func() {
+			It("should only return the matching keys", func() {
+				Expect(client.Set("ns:first", "value", 0)).To(Succeed())
+				Expect(client.Set("ns:second", "value", 0)).To(Succeed())
+				Expect(client.Set("other:third", "value", 0)).To(Succeed())
+
+				keys, err := client.Scan("ns:*")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(keys).To(Equal([]string{"ns:first", "ns:second"}))
+			})
+		})
+	})
+
+	Context("Connecting to the server", func() {
+		When("the server requires a password and the right one is configured", func() {
+			It("should authenticate", func() {
+				server.RequirePassword("secret")
+				authenticated := redisClient.New(redisClient.Config{Addr: server.Addr(), Password: "secret"})
+				defer authenticated.Close()
+
+				Expect(authenticated.Set("key", "value", 0)).To(Succeed())
+			})
+		})
+
+		When("the server requires a password and none is configured", func() {
+			It("should return an error", func() {
+				server.RequirePassword("secret")
+				Expect(client.Set("key", "value", 0)).To(HaveOccurred())
+			})
+		})
+
+		When("the server cannot be reached", func() {
+			It("should return an error", func() {
+				server.Close()
+				unreachable := redisClient.New(redisClient.Config{Addr: server.Addr(), Timeout: time.Millisecond * 100})
+				defer unreachable.Close()
+
+				_, err := unreachable.Get("key")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("the client has been closed", func() {
+			It("should return an error", func() {
+				client.Close()
+				_, err := client.Get("key")
+				Expect(err).To(Equal(errors.New(redisClient.ErrorClosed)))
+			})
+		})
+	})
+})
@@ -0,0 +1,22 @@
+package redisClient
+
+import "time"
+
+const (
+	DefaultTimeout = time.Second * 2
+	DefaultMaxIdle = 8
+)
+
+func New(config Config) Client {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if config.MaxIdle <= 0 {
+		config.MaxIdle = DefaultMaxIdle
+	}
+
+	return &client{
+		config: config,
+	}
+}
@@ -0,0 +1,247 @@
+package redisTest
+
+import (
+	"bufio"
+	"errors"
+	redisClient "github.com/jddcode/tech-test-ennismore/internal/redis-client"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type item struct {
+	value   string
+	expires time.Time
+}
+
+// Server is an in-process stand-in for a redis server which understands
+// enough of RESP and the command set for the service's redis client to be
+// tested against it. It listens on a random local port.
+type Server struct {
+	listener net.Listener
+	password string
+	conns    map[net.Conn]bool
+	items    map[string]item
+	commands int
+	lock     sync.Mutex
+}
+
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		listener: listener,
+		conns:    make(map[net.Conn]bool),
+		items:    make(map[string]item),
+	}
+	go server.serve()
+	return server, nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops listening and drops every open connection, as a server which
+// has gone away would
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// RequirePassword makes new connections authenticate with the password
+func (s *Server) RequirePassword(password string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.password = password
+}
+
+// TTL returns how long the key has left to live, or zero if it does not
+// exist or never expires
+func (s *Server) TTL(key string) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	myItem, exists := s.items[key]
+	if !exists || myItem.expires.IsZero() {
+		return 0
+	}
+	return time.Until(myItem.expires)
+}
+
+// Commands returns the number of commands the server has handled
+func (s *Server) Commands() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.commands
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	s.lock.Lock()
+	s.conns[conn] = true
+	password := s.password
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	authenticated := len(password) < 1
+	for {
+		request, err := redisClient.ReadReply(reader)
+		if err != nil {
+			return
+		}
+
+		parts, ok := request.([]interface{})
+		if !ok || len(parts) < 1 {
+			conn.Write(redisClient.EncodeReply(errors.New("ERR expected a command")))
+			continue
+		}
+
+		args := make([]string, len(parts))
+		for i, part := range parts {
+			args[i], _ = part.(string)
+		}
+
+		command := strings.ToUpper(args[0])
+		if command == "AUTH" {
+			authenticated = len(args) == 2 && args[1] == password
+			if !authenticated {
+				conn.Write(redisClient.EncodeReply(errors.New("WRONGPASS invalid password")))
+				continue
+			}
+			conn.Write(redisClient.EncodeReply(redisClient.SimpleString("OK")))
+			continue
+		}
+
+		if !authenticated {
+			conn.Write(redisClient.EncodeReply(errors.New("NOAUTH Authentication required")))
+			continue
+		}
+		conn.Write(redisClient.EncodeReply(s.execute(command, args[1:])))
+	}
+}
+
+func (s *Server) execute(command string, args []string) interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.commands++
+	s.expire()
+	switch command {
+	case "PING":
+		return redisClient.SimpleString("PONG")
+	case "SELECT":
+		return redisClient.SimpleString("OK")
+	case "GET":
+		if len(args) != 1 {
+			return errors.New("ERR wrong number of arguments for 'get' command")
+		}
+
+		if myItem, exists := s.items[args[0]]; exists {
+			return myItem.value
+		}
+		return nil
+	case "SET":
+		return s.set(args)
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if _, exists := s.items[key]; exists {
+				delete(s.items, key)
+				deleted++
+			}
+		}
+		return deleted
+	case "SCAN":
+		return s.scan(args)
+	default:
+		return errors.New("ERR unknown command '" + command + "'")
+	}
+}
+
+func (s *Server) set(args []string) interface{} {
+	if len(args) < 2 {
+		return errors.New("ERR wrong number of arguments for 'set' command")
+	}
+
+	myItem := item{value: args[1]}
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return errors.New("ERR syntax error")
+		}
+
+		amount, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil || amount < 1 {
+			return errors.New("ERR invalid expire time in 'set' command")
+		}
+
+		switch strings.ToUpper(args[i]) {
+		case "EX":
+			myItem.expires = time.Now().Add(time.Duration(amount) * time.Second)
+		case "PX":
+			myItem.expires = time.Now().Add(time.Duration(amount) * time.Millisecond)
+		default:
+			return errors.New("ERR syntax error")
+		}
+	}
+
+	s.items[args[0]] = myItem
+	return redisClient.SimpleString("OK")
+}
+
+// scan returns every matching key in one batch, which is a valid if lazy
+// implementation of the SCAN cursor contract
+func (s *Server) scan(args []string) interface{} {
+	pattern := "*"
+	for i := 1; i+1 < len(args); i += 2 {
+		if strings.ToUpper(args[i]) == "MATCH" {
+			pattern = args[i+1]
+		}
+	}
+
+	keys := make([]string, 0)
+	for key := range s.items {
+		if matched, _ := path.Match(pattern, key); matched {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return []interface{}{"0", keys}
+}
+
+func (s *Server) expire() {
+	now := time.Now()
+	for key, myItem := range s.items {
+		if !myItem.expires.IsZero() && !now.Before(myItem.expires) {
+			delete(s.items, key)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package redisClient
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SimpleString is encoded as a RESP simple string such as +OK rather than as
+// a bulk string
+type SimpleString string
+
+// EncodeCommand encodes a command as a RESP array of bulk strings
+func EncodeCommand(args ...string) []byte {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	return EncodeReply(values)
+}
+
+// EncodeReply encodes strings as bulk strings, nil as a nil bulk string and
+// errors as error replies, along with integers, simple strings and arrays of
+// any of these
+func EncodeReply(value interface{}) []byte {
+	buffer := bytes.Buffer{}
+	encode(&buffer, value)
+	return buffer.Bytes()
+}
+
+func encode(buffer *bytes.Buffer, value interface{}) {
+	switch typed := value.(type) {
+	case nil:
+		buffer.WriteString("$-1\r\n")
+	case SimpleString:
+		fmt.Fprintf(buffer, "+%s\r\n", string(typed))
+	case error:
+		fmt.Fprintf(buffer, "-%s\r\n", typed.Error())
+	case int:
+		fmt.Fprintf(buffer, ":%d\r\n", typed)
+	case int64:
+		fmt.Fprintf(buffer, ":%d\r\n", typed)
+	case string:
+		fmt.Fprintf(buffer, "$%d\r\n%s\r\n", len(typed), typed)
+	case []string:
+		fmt.Fprintf(buffer, "*%d\r\n", len(typed))
+		for _, item := range typed {
+			encode(buffer, item)
+		}
+	case []interface{}:
+		fmt.Fprintf(buffer, "*%d\r\n", len(typed))
+		for _, item := range typed {
+			encode(buffer, item)
+		}
+	default:
+		encode(buffer, fmt.Sprint(typed))
+	}
+}
+
+// ReadReply reads a single RESP value. Bulk and simple strings are returned
+// as strings, integers as int64, arrays as []interface{} and nil replies as
+// nil. An error reply is returned as the error.
+func ReadReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 1 {
+		return nil, fmt.Errorf(ErrorReply, "empty line")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf(ErrorReply, err.Error())
+		}
+
+		if length < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf(ErrorReply, err.Error())
+		}
+
+		if length < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, length)
+		for i := range items {
+			items[i], err = ReadReply(reader)
+			if _, isRedisError := err.(redisError); err != nil && !isRedisError {
+				return nil, err
+			}
+
+			if err != nil {
+				items[i] = err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf(ErrorReply, "unknown type "+string(line[0]))
+	}
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("line not terminated with CRLF")
+	}
+	return line[:len(line)-2], nil
+}
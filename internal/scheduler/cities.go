@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrorNoCities     = "At least one city must be configured for pre-warming"
+	ErrorCityPosition = "Unrecognised co-ordinates for city %s: %s"
+)
+
+type City struct {
+	Name     string
+	Position *structs.CoOrdinates
+}
+
+// ParseCities reads a semicolon delimited list of cities, each of which may
+// give its co-ordinates explicitly, eg. "chicago;new york=40.71,-74.01"
+func ParseCities(list string) ([]City, error) {
+	cities := make([]City, 0)
+	for _, item := range strings.Split(list, ";") {
+		name, position := item, ""
+		if equals := strings.Index(item, "="); equals >= 0 {
+			name, position = item[:equals], item[equals+1:]
+		}
+
+		city := City{Name: strings.TrimSpace(name)}
+		if len(city.Name) < 1 {
+			continue
+		}
+
+		if len(strings.TrimSpace(position)) > 0 {
+			pos, ok := parsePosition(position)
+			if !ok {
+				return nil, fmt.Errorf(ErrorCityPosition, city.Name, position)
+			}
+			city.Position = &pos
+		}
+		cities = append(cities, city)
+	}
+
+	if len(cities) < 1 {
+		return nil, errors.New(ErrorNoCities)
+	}
+	return cities, nil
+}
+
+// Positions returns the explicit co-ordinates of any cities which have them,
+// keyed by city name
+func Positions(cities []City) map[string]structs.CoOrdinates {
+	positions := make(map[string]structs.CoOrdinates)
+	for _, city := range cities {
+		if city.Position != nil {
+			positions[city.Name] = *city.Position
+		}
+	}
+	return positions
+}
+
+func parsePosition(position string) (structs.CoOrdinates, bool) {
+	parts := strings.Split(position, ",")
+	if len(parts) != 2 {
+		return structs.CoOrdinates{}, false
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return structs.CoOrdinates{}, false
+	}
+
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return structs.CoOrdinates{}, false
+	}
+
+	return structs.CoOrdinates{
+		Latitude:  lat,
+		Longitude: lon,
+	}, true
+}
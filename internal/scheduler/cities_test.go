@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"fmt"
+	"github.com/jddcode/tech-test-ennismore/internal/structs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Configured cities", func() {
+	Context("Parsing the city list", func() {
+		When("cities are given with and without co-ordinates", func() {
+			It("should return each city", func() {
+				cities, err := ParseCities("chicago; new york=40.71,-74.01;")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cities).To(Equal([]City{
+					City{Name: "chicago"},
+					City{Name: "new york", Position: &structs.CoOrdinates{Latitude: 40.71, Longitude: -74.01}},
+				}))
+
+				Expect(Positions(cities)).To(Equal(map[string]structs.CoOrdinates{
+					"new york": structs.CoOrdinates{Latitude: 40.71, Longitude: -74.01},
+				}))
+			})
+		})
+
+		When("a city has invalid co-ordinates", func() {
+			It("should return an error", func() {
+				_, err := ParseCities("chicago=91,-87.62")
+				Expect(err).To(Equal(fmt.Errorf(ErrorCityPosition, "chicago", "91,-87.62")))
+			})
+		})
+
+		When("no cities are given", func() {
+			It("should return an error", func() {
+				_, err := ParseCities(" ; ")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// New creates a scheduler which pre-warms the configured cities through the
+// warmer. It does nothing until Start is called.
+func New(cache Cache, warmer Warmer, config Config) Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scheduler{
+		cache:  cache,
+		warmer: warmer,
+		config: config.withDefaults(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// NewInterval creates a Schedule which ticks at a fixed interval
+func NewInterval(every time.Duration) (Schedule, error) {
+	if every <= 0 {
+		return nil, fmt.Errorf(ErrorInterval, every)
+	}
+	return interval{every: every}, nil
+}
+
+// NewCron creates a Schedule from a five field crontab expression, eg.
+// "*/20 5-22 * * *", evaluated in the local time zone
+func NewCron(spec string) (Schedule, error) {
+	return parseCron(spec)
+}
+
+func (c Config) withDefaults() Config {
+	if c.Schedule == nil {
+		c.Schedule = interval{every: DefaultInterval}
+	}
+
+	if c.Jitter < 0 {
+		c.Jitter = 0
+	}
+
+	if c.Concurrency < 1 {
+		c.Concurrency = DefaultConcurrency
+	}
+	return c
+}
@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ErrorCronFields = "A schedule must have five fields (minute hour day month weekday): %s"
+	ErrorCronField  = "Unrecognised schedule field: %s"
+	ErrorInterval   = "The interval must be greater than zero: %s"
+
+	cronSearchLimit = 5
+)
+
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+type interval struct {
+	every time.Duration
+}
+
+func (i interval) Next(after time.Time) time.Time {
+	return after.Add(i.every)
+}
+
+// cron matches times the same way as a crontab entry. Each field is held as a
+// bit set of the values it matches. As with cron, when both the day of the
+// month and the day of the week are restricted a time matching either runs.
+type cron struct {
+	minutes, hours, days, months, weekdays uint64
+	anyDay, anyWeekday                     bool
+}
+
+func (c cron) Next(after time.Time) time.Time {
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(cronSearchLimit, 0, 0)
+
+	for next.Before(limit) {
+		if !has(c.months, int(next.Month())) {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+
+		if !c.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+
+		if !has(c.hours, next.Hour()) {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+
+		if !has(c.minutes, next.Minute()) {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return limit
+}
+
+func (c cron) matchesDay(t time.Time) bool {
+	day := has(c.days, t.Day())
+	weekday := has(c.weekdays, int(t.Weekday()))
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+func parseCron(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(ErrorCronFields, spec)
+	}
+
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	// Sunday may be written as 0 or 7
+	if has(sets[4], 7) {
+		sets[4] |= 1
+	}
+
+	return cron{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField understands comma separated lists of *, single values and
+// ranges, each optionally followed by a /step
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			value, err := strconv.Atoi(part[slash+1:])
+			if err != nil || value < 1 {
+				return 0, fmt.Errorf(ErrorCronField, field)
+			}
+			step = value
+			part = part[:slash]
+		}
+
+		from, to := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			start, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf(ErrorCronField, field)
+			}
+			end, err := strconv.Atoi(bounds[1])
+			if err != nil {
+				return 0, fmt.Errorf(ErrorCronField, field)
+			}
+			from, to = start, end
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf(ErrorCronField, field)
+			}
+			from, to = value, value
+			if step > 1 {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf(ErrorCronField, field)
+		}
+
+		for value := from; value <= to; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
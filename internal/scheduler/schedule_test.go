@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Schedules", func() {
+	var (
+		start time.Time
+	)
+
+	BeforeEach(func() {
+		start = time.Date(2022, time.January, 1, 15, 7, 30, 0, time.UTC)
+	})
+
+	Context("Ticking at an interval", func() {
+		When("an interval is given", func() {
+			It("should tick after the interval", func() {
+				schedule, err := NewInterval(time.Minute * 15)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(schedule.Next(start)).To(Equal(start.Add(time.Minute * 15)))
+			})
+		})
+
+		When("the interval is not positive", func() {
+			It("should return an error", func() {
+				_, err := NewInterval(0)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Ticking on a cron expression", func() {
+		DescribeTable("working out the next tick",
+			func(spec string, expected time.Time) {
+				schedule, err := NewCron(spec)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(schedule.Next(start)).To(Equal(expected))
+			},
+			Entry("every minute", "* * * * *", time.Date(2022, time.January, 1, 15, 8, 0, 0, time.UTC)),
+			Entry("every twenty minutes", "*/20 * * * *", time.Date(2022, time.January, 1, 15, 20, 0, 0, time.UTC)),
+			Entry("a fixed time later today", "30 18 * * *", time.Date(2022, time.January, 1, 18, 30, 0, 0, time.UTC)),
+			Entry("a fixed time tomorrow", "0 6 * * *", time.Date(2022, time.January, 2, 6, 0, 0, 0, time.UTC)),
+			Entry("a range of hours", "0 5-7,20 * * *", time.Date(2022, time.January, 1, 20, 0, 0, 0, time.UTC)),
+			Entry("weekdays only", "0 6 * * 1-5", time.Date(2022, time.January, 3, 6, 0, 0, 0, time.UTC)),
+			Entry("sundays written as seven", "0 6 * * 7", time.Date(2022, time.January, 2, 6, 0, 0, 0, time.UTC)),
+			Entry("a day of the month", "0 0 15 * *", time.Date(2022, time.January, 15, 0, 0, 0, 0, time.UTC)),
+			Entry("a day of the month or a weekday", "0 0 15 * 1", time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC)),
+			Entry("a later month", "0 0 1 3 *", time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)),
+		)
+
+		DescribeTable("rejecting invalid expressions",
+			func(spec string) {
+				_, err := NewCron(spec)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("too few fields", "* * * *"),
+			Entry("an unknown value", "x * * * *"),
+			Entry("a minute out of range", "60 * * * *"),
+			Entry("a backwards range", "0 9-5 * * *"),
+			Entry("a zero step", "*/0 * * * *"),
+		)
+	})
+})
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/cache"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultInterval    = time.Minute * 15
+	DefaultJitter      = time.Second * 30
+	DefaultConcurrency = 4
+)
+
+type Cache interface {
+	Lookup(city string) (cache.Entry, error)
+}
+
+type Warmer interface {
+	Warm(ctx context.Context, city string) error
+	Key(city string) string
+}
+
+type Scheduler interface {
+	Start()
+	Stop()
+}
+
+type Config struct {
+	Cities      []City
+	Schedule    Schedule
+	Jitter      time.Duration
+	Concurrency int
+}
+
+// scheduler refreshes the forecast for each configured city once at start up
+// and then on every tick of its schedule. A city is only refreshed when its
+// cached entry would expire before the following tick, so a forecast is never
+// left to go cold between runs. Each refresh is delayed by a random jitter so
+// the cities do not all reach the upstream services at once.
+type scheduler struct {
+	cache   Cache
+	warmer  Warmer
+	config  Config
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	started sync.Once
+}
+
+func (s *scheduler) Start() {
+	s.started.Do(func() {
+		s.running.Add(1)
+		go s.run()
+	})
+}
+
+// Stop cancels any refreshes in progress and waits for them to finish
+func (s *scheduler) Stop() {
+	s.cancel()
+	s.running.Wait()
+}
+
+func (s *scheduler) run() {
+	defer s.running.Done()
+
+	s.warmAll(s.config.Schedule.Next(time.Now()))
+
+	for {
+		next := s.config.Schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.warmAll(s.config.Schedule.Next(next))
+	}
+}
+
+// warmAll refreshes every city which is due, running no more than the
+// configured number of refreshes at once
+func (s *scheduler) warmAll(until time.Time) {
+	slots := make(chan struct{}, s.config.Concurrency)
+	wait := sync.WaitGroup{}
+
+	for _, city := range s.due(until) {
+		select {
+		case <-s.ctx.Done():
+			wait.Wait()
+			return
+		case slots <- struct{}{}:
+		}
+
+		wait.Add(1)
+		go func(city string) {
+			defer func() {
+				<-slots
+				wait.Done()
+			}()
+
+			if !s.sleep(s.jitter()) {
+				return
+			}
+
+			if err := s.warmer.Warm(s.ctx, city); err != nil {
+				log.Printf("Could not pre-warm the forecast for %s: %s", city, err.Error())
+			}
+		}(city)
+	}
+	wait.Wait()
+}
+
+// due returns the cities whose cached entry expires before until, allowing
+// for the jitter which may delay the following run's refresh
+func (s *scheduler) due(until time.Time) []string {
+	until = until.Add(s.config.Jitter)
+
+	cities := make([]string, 0, len(s.config.Cities))
+	for _, city := range s.config.Cities {
+		if entry, err := s.cache.Lookup(s.warmer.Key(city.Name)); err == nil && entry.Expires.After(until) {
+			continue
+		}
+		cities = append(cities, city.Name)
+	}
+	return cities
+}
+
+func (s *scheduler) jitter() time.Duration {
+	if s.config.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.config.Jitter)))
+}
+
+// sleep waits for the duration, returning false if the scheduler is stopped
+// first
+func (s *scheduler) sleep(duration time.Duration) bool {
+	if duration <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
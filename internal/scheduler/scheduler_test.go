@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/jddcode/tech-test-ennismore/internal/handler-weather/cache"
+	"github.com/jddcode/tech-test-ennismore/internal/mocks"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Unit Tests")
+}
+
+var _ = Describe("Pre-warming scheduler", func() {
+	var (
+		mockController *gomock.Controller
+		mockCache      *mocks.MockCache
+		mockWarmer     *mocks.MockWarmer
+		cities         []City
+	)
+
+	BeforeEach(func() {
+		mockController = gomock.NewController(GinkgoT())
+		mockCache = mocks.NewMockCache(mockController)
+		mockWarmer = mocks.NewMockWarmer(mockController)
+		mockWarmer.EXPECT().Key(gomock.Any()).DoAndReturn(func(city string) string {
+			return city
+		}).AnyTimes()
+		cities = []City{City{Name: "chicago"}, City{Name: "boston"}}
+	})
+
+	AfterEach(func() {
+		mockController.Finish()
+	})
+
+	newScheduler := func(config Config) *scheduler {
+		config.Cities = cities
+		return New(mockCache, mockWarmer, config).(*scheduler)
+	}
+
+	Context("Warming the configured cities", func() {
+		When("no city is cached", func() {
+			It("should warm every city", func() {
+				mockCache.EXPECT().Lookup(gomock.Any()).Return(cache.Entry{}, errors.New(cache.ErrorCacheMiss)).Times(2)
+				mockWarmer.EXPECT().Warm(gomock.Any(), "chicago").Return(nil)
+				mockWarmer.EXPECT().Warm(gomock.Any(), "boston").Return(nil)
+
+				newScheduler(Config{}).warmAll(time.Now().Add(time.Minute * 15))
+			})
+		})
+
+		When("a city is cached beyond the next run", func() {
+			It("should only warm the cities which would expire first", func() {
+				mockCache.EXPECT().Lookup("chicago").Return(cache.Entry{Expires: time.Now().Add(time.Hour)}, nil)
+				mockCache.EXPECT().Lookup("boston").Return(cache.Entry{Expires: time.Now().Add(time.Minute * 10)}, nil)
+				mockWarmer.EXPECT().Warm(gomock.Any(), "boston").Return(nil)
+
+				newScheduler(Config{}).warmAll(time.Now().Add(time.Minute * 15))
+			})
+		})
+
+		When("a city expires within the jitter after the next run", func() {
+			It("should be due", func() {
+				cities = cities[:1]
+				mockCache.EXPECT().Lookup("chicago").Return(cache.Entry{Expires: time.Now().Add(time.Minute * 16)}, nil)
+
+				due := newScheduler(Config{Jitter: time.Minute * 2}).due(time.Now().Add(time.Minute * 15))
+				Expect(due).To(Equal([]string{"chicago"}))
+			})
+		})
+
+		When("a city cannot be warmed", func() {
+			It("should carry on with the other cities", func() {
+				mockCache.EXPECT().Lookup(gomock.Any()).Return(cache.Entry{}, errors.New(cache.ErrorCacheMiss)).Times(2)
+				mockWarmer.EXPECT().Warm(gomock.Any(), "chicago").Return(errors.New("Could not find co-ordinates for city: chicago"))
+				mockWarmer.EXPECT().Warm(gomock.Any(), "boston").Return(nil)
+
+				newScheduler(Config{}).warmAll(time.Now())
+			})
+		})
+
+		When("there are more cities than the concurrency limit", func() {
+			It("should never run more refreshes at once than the limit", func() {
+				cities = []City{}
+				for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+					cities = append(cities, City{Name: name})
+				}
+
+				lock := sync.Mutex{}
+				running, most := 0, 0
+				mockCache.EXPECT().Lookup(gomock.Any()).Return(cache.Entry{}, errors.New(cache.ErrorCacheMiss)).Times(6)
+				mockWarmer.EXPECT().Warm(gomock.Any(), gomock.Any()).Times(6).DoAndReturn(func(ctx context.Context, city string) error {
+					lock.Lock()
+					running++
+					if running > most {
+						most = running
+					}
+					lock.Unlock()
+
+					time.Sleep(time.Millisecond * 10)
+
+					lock.Lock()
+					running--
+					lock.Unlock()
+					return nil
+				})
+
+				newScheduler(Config{Concurrency: 2}).warmAll(time.Now())
+				Expect(most).To(Equal(2))
+			})
+		})
+	})
+
+	Context("Running on a schedule", func() {
+		When("the scheduler is started", func() {
+			It("should warm at start up and on each tick until stopped", func() {
+				cities = cities[:1]
+				warmed := make(chan struct{}, 10)
+				mockCache.EXPECT().Lookup("chicago").Return(cache.Entry{}, errors.New(cache.ErrorCacheMiss)).AnyTimes()
+				mockWarmer.EXPECT().Warm(gomock.Any(), "chicago").MinTimes(3).DoAndReturn(func(ctx context.Context, city string) error {
+					warmed <- struct{}{}
+					return nil
+				})
+
+				schedule, err := NewInterval(time.Millisecond * 20)
+				Expect(err).ToNot(HaveOccurred())
+
+				myScheduler := newScheduler(Config{Schedule: schedule})
+				myScheduler.Start()
+				for i := 0; i < 3; i++ {
+					Eventually(warmed).Should(Receive())
+				}
+				myScheduler.Stop()
+			})
+		})
+
+		When("the scheduler is stopped during the jitter", func() {
+			It("should not warm the city", func() {
+				cities = cities[:1]
+				mockCache.EXPECT().Lookup("chicago").Return(cache.Entry{}, errors.New(cache.ErrorCacheMiss))
+
+				myScheduler := newScheduler(Config{Jitter: time.Hour})
+				myScheduler.Start()
+				time.Sleep(time.Millisecond * 20)
+				myScheduler.Stop()
+			})
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package structs
+
+// Candidate is one of the places a geocoding query may have meant, with
+// enough detail to tell it apart from the others. Place is the query which
+// finds this candidate alone.
+type Candidate struct {
+	Name        string
+	Class, Type string
+	Importance  float64
+	BoundingBox BoundingBox
+	Position    CoOrdinates
+	Place       Place
+}
+
+type BoundingBox struct {
+	South, North, West, East float64
+}
+
+// Contains reports whether the position is within the box
+func (b BoundingBox) Contains(pos CoOrdinates) bool {
+	return pos.Latitude >= b.South && pos.Latitude <= b.North &&
+		pos.Longitude >= b.West && pos.Longitude <= b.East
+}
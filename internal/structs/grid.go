@@ -0,0 +1,21 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Grid is the NWS forecast grid cell covering a location. Location names the
+// nearest place the NWS knows of, eg. "Chicago, IL".
+type Grid struct {
+	ID       string
+	X, Y     int
+	Forecast string
+	Location string
+}
+
+// Key identifies the grid cell, eg. "lot/76,73", so every place which falls
+// in the same cell can share one forecast
+func (g Grid) Key() string {
+	return fmt.Sprintf("%s/%d,%d", strings.ToLower(g.ID), g.X, g.Y)
+}
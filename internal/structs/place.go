@@ -0,0 +1,34 @@
+package structs
+
+import "strings"
+
+// Place is a city, or a postcode, optionally narrowed down to a state or
+// region, in the country given by its lower case ISO 3166-1 alpha-2 code
+type Place struct {
+	City, Postcode, State, Country string
+}
+
+// Key identifies the place, eg. "springfield,illinois,us", "chicago,us" when
+// no state was given or "60601,us" for a postcode
+func (p Place) Key() string {
+	return strings.ToLower(strings.Join(append(p.parts(), p.Country), ","))
+}
+
+// String describes the place for people, eg. "springfield, illinois, US"
+func (p Place) String() string {
+	parts := p.parts()
+	if len(p.Country) > 0 {
+		parts = append(parts, strings.ToUpper(p.Country))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (p Place) parts() []string {
+	parts := make([]string, 0, 4)
+	for _, part := range []string{p.City, p.Postcode, p.State} {
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -13,6 +13,7 @@ type Weather struct {
 	Forecast struct {
 		Short, Long string
 	}
+	Updated, ValidUntil time.Time
 }
 
 func (w Weather) GetForecast() string {
@@ -2,8 +2,8 @@ package weatherFetcher
 
 import httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
 
-func New() WeatherFetcher {
+func New(web httpClient.Client) WeatherFetcher {
 	return weatherFetcher{
-		web: httpClient.New(),
+		web: web,
 	}
 }
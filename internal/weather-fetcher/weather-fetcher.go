@@ -1,6 +1,7 @@
 package weatherFetcher
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,10 +14,10 @@ import (
 )
 
 const (
-	ErrorGetRequest         = "Error fetching weather report via GET: %s"
+	ErrorGetRequest         = "Error fetching weather report via GET: %w"
 	ErrorUnmarshalLookup    = "Error unmarshalling the co-ordinate weather lookup: %s"
 	ErrorNoForecastResource = "Error finding the forecast resource from the co-ordinate weather lookup"
-	ErrorGetForecast        = "Error fetching forecast via GET: %s"
+	ErrorGetForecast        = "Error fetching forecast via GET: %w"
 	ErrorUnmarshalForecast  = "Error unarmshalling the forecast: %s"
 	ErrorUnusualWindSpeed   = "Error converting wind speed measures to integers: %s"
 	ErrorUnusualStartTime   = "Error converting start time to time.Time: %s"
@@ -25,45 +26,87 @@ const (
 
 //go:generate mockgen -destination=../mocks/mock-weather-fetcher.go -package=mocks . WeatherFetcher
 type WeatherFetcher interface {
-	Fetch(pos structs.CoOrdinates) ([]structs.Weather, error)
+	Fetch(ctx context.Context, pos structs.CoOrdinates) ([]structs.Weather, error)
+	Locate(ctx context.Context, pos structs.CoOrdinates) (structs.Grid, error)
+	Forecast(ctx context.Context, grid structs.Grid) ([]structs.Weather, error)
 }
 
 type weatherFetcher struct {
 	web httpClient.Client
 }
 
-func (w weatherFetcher) Fetch(pos structs.CoOrdinates) ([]structs.Weather, error) {
-	resp, err := w.web.Get(fmt.Sprintf("https://api.weather.gov/points/%.5f,%.5f", pos.Latitude, pos.Longitude))
+func (w weatherFetcher) Fetch(ctx context.Context, pos structs.CoOrdinates) ([]structs.Weather, error) {
+	grid, err := w.Locate(ctx, pos)
 	if err != nil {
-		return nil, fmt.Errorf(ErrorGetRequest, err.Error())
+		return nil, err
 	}
+	return w.Forecast(ctx, grid)
+}
 
-	lookupResult := fetcherStructs.ResponseCoOrdinateLookup{}
-	err = json.Unmarshal([]byte(resp), &lookupResult)
+// Locate finds the NWS grid cell, and its forecast resource, for a location
+func (w weatherFetcher) Locate(ctx context.Context, pos structs.CoOrdinates) (structs.Grid, error) {
+	body, err := w.web.Open(ctx, fmt.Sprintf("https://api.weather.gov/points/%.5f,%.5f", pos.Latitude, pos.Longitude))
 	if err != nil {
-		return nil, fmt.Errorf(ErrorUnmarshalLookup, err.Error())
+		return structs.Grid{}, fmt.Errorf(ErrorGetRequest, err)
+	}
+	defer body.Close()
+
+	lookupResult := fetcherStructs.ResponseCoOrdinateLookup{}
+	if err := json.NewDecoder(body).Decode(&lookupResult); err != nil {
+		if httpClient.IsBodyTooLarge(err) {
+			return structs.Grid{}, fmt.Errorf(ErrorGetRequest, err)
+		}
+		return structs.Grid{}, fmt.Errorf(ErrorUnmarshalLookup, err.Error())
 	}
 
 	if len(lookupResult.Properties.Forecast) < 1 {
+		return structs.Grid{}, errors.New(ErrorNoForecastResource)
+	}
+
+	location := lookupResult.Properties.RelativeLocation.Properties
+	grid := structs.Grid{
+		ID:       lookupResult.Properties.GridID,
+		X:        lookupResult.Properties.GridX,
+		Y:        lookupResult.Properties.GridY,
+		Forecast: lookupResult.Properties.Forecast,
+		Location: location.City,
+	}
+
+	if len(location.City) > 0 && len(location.State) > 0 {
+		grid.Location = location.City + ", " + location.State
+	}
+	return grid, nil
+}
+
+// Forecast fetches the forecast for a grid cell found by Locate
+func (w weatherFetcher) Forecast(ctx context.Context, grid structs.Grid) ([]structs.Weather, error) {
+	if len(grid.Forecast) < 1 {
 		return nil, errors.New(ErrorNoForecastResource)
 	}
 
-	resp, err = w.web.Get(lookupResult.Properties.Forecast)
+	body, err := w.web.Open(ctx, grid.Forecast)
 	if err != nil {
-		return nil, fmt.Errorf(ErrorGetForecast, err.Error())
+		return nil, fmt.Errorf(ErrorGetForecast, err)
 	}
+	defer body.Close()
 
 	forecastData := fetcherStructs.ResponseForecast{}
-	err = json.Unmarshal([]byte(resp), &forecastData)
-	if err != nil {
+	if err := json.NewDecoder(body).Decode(&forecastData); err != nil {
+		if httpClient.IsBodyTooLarge(err) {
+			return nil, fmt.Errorf(ErrorGetForecast, err)
+		}
 		return nil, fmt.Errorf(ErrorUnmarshalForecast, err.Error())
 	}
 
+	validUntil, _ := w.parseValidTimes(forecastData.Properties.ValidTimes)
+
 	myWeather := make([]structs.Weather, 0)
 	for _, period := range forecastData.Properties.Periods {
 		weather := structs.Weather{
 			IsDay:                period.IsDaytime,
 			TemperatureFarenheit: period.Temperature,
+			Updated:              forecastData.Properties.UpdateTime,
+			ValidUntil:           validUntil,
 		}
 
 		weather.Start, err = w.parseTimeString(period.StartTime)
@@ -115,8 +158,15 @@ func (w weatherFetcher) getWindSpeeds(windSpeedStr string) (int, int, error) {
 	}
 }
 
+// parseTimeString reads an RFC 3339 time, keeping its UTC offset so it can be
+// compared with the validTimes interval. A time without an offset is read as
+// UTC.
 func (w weatherFetcher) parseTimeString(timeString string) (time.Time, error) {
-	if len(timeString) < 18 {
+	if myTime, err := time.Parse(time.RFC3339, timeString); err == nil {
+		return myTime, nil
+	}
+
+	if len(timeString) < 19 {
 		return time.Time{}, errors.New("invalid time string")
 	}
 	myTime, err := time.Parse("2006-01-02T15:04:05", timeString[0:19])
@@ -125,3 +175,69 @@ func (w weatherFetcher) parseTimeString(timeString string) (time.Time, error) {
 	}
 	return myTime, nil
 }
+
+// parseValidTimes returns the end of an ISO 8601 interval such as
+// "2022-01-01T12:00:00+00:00/P7DT13H" as used by the NWS validTimes field
+func (w weatherFetcher) parseValidTimes(validTimes string) (time.Time, error) {
+	parts := strings.Split(validTimes, "/")
+	if len(parts) != 2 {
+		return time.Time{}, errors.New("invalid interval")
+	}
+
+	start, err := time.Parse(time.RFC3339, parts[0])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	duration, err := w.parseDuration(parts[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.Add(duration), nil
+}
+
+// parseDuration supports the day, hour, minute and second designators of an
+// ISO 8601 duration, which is all the NWS uses
+func (w weatherFetcher) parseDuration(isoDuration string) (time.Duration, error) {
+	if len(isoDuration) < 3 || isoDuration[0] != 'P' {
+		return 0, errors.New("invalid duration")
+	}
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, char := range isoDuration[1:] {
+		switch {
+		case char >= '0' && char <= '9':
+			number += string(char)
+			continue
+		case char == 'T':
+			inTime = true
+			continue
+		}
+
+		value, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, errors.New("invalid duration")
+		}
+		number = ""
+
+		switch {
+		case char == 'D' && !inTime:
+			total += time.Duration(value) * 24 * time.Hour
+		case char == 'H' && inTime:
+			total += time.Duration(value) * time.Hour
+		case char == 'M' && inTime:
+			total += time.Duration(value) * time.Minute
+		case char == 'S' && inTime:
+			total += time.Duration(value) * time.Second
+		default:
+			return 0, errors.New("invalid duration")
+		}
+	}
+
+	if len(number) > 0 {
+		return 0, errors.New("invalid duration")
+	}
+	return total, nil
+}
@@ -1,14 +1,21 @@
 package weatherFetcher
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
+	httpClient "github.com/jddcode/tech-test-ennismore/internal/http-client"
 	"github.com/jddcode/tech-test-ennismore/internal/mocks"
 	"github.com/jddcode/tech-test-ennismore/internal/structs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestSuite(t *testing.T) {
@@ -16,6 +23,10 @@ func TestSuite(t *testing.T) {
 	RunSpecs(t, "Unit Tests")
 }
 
+func stream(data string) io.ReadCloser {
+	return ioutil.NopCloser(strings.NewReader(data))
+}
+
 var _ = Describe("Weather forecast handler", func() {
 	var (
 		mockController *gomock.Controller
@@ -38,17 +49,17 @@ var _ = Describe("Weather forecast handler", func() {
 	Context("Fetching a weather forecast for a specific location", func() {
 		When("the initial lat/long based GET request fails", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return("", errors.New("some http error"))
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(nil, errors.New("some http error"))
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
-				Expect(err).To(Equal(fmt.Errorf(ErrorGetRequest, "some http error")))
+				Expect(err).To(Equal(fmt.Errorf(ErrorGetRequest, errors.New("some http error"))))
 			})
 		})
 
 		When("the initial lat/long based GET request response cannot be unmarshalled", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return("---", nil)
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream("---"), nil)
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).To(Equal(fmt.Errorf(ErrorUnmarshalLookup, "invalid character '-' in numeric literal")))
 			})
@@ -56,8 +67,8 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("the initial lat/long based GET request gives a blank or unpopulated forecast URL", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":""}}`, nil)
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":""}}`), nil)
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).To(Equal(errors.New(ErrorNoForecastResource)))
 			})
@@ -65,19 +76,19 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("the forecast URL has an error during the GET request", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":"http://example.org"}}`, nil)
-				mockHttpClient.EXPECT().Get("http://example.org").Return("", errors.New("some http error"))
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(nil, errors.New("some http error"))
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
-				Expect(err).To(Equal(fmt.Errorf(ErrorGetForecast, "some http error")))
+				Expect(err).To(Equal(fmt.Errorf(ErrorGetForecast, errors.New("some http error"))))
 			})
 		})
 
 		When("the data received from the forecast lookup cannot be unmarshalled", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":"http://example.org"}}`, nil)
-				mockHttpClient.EXPECT().Get("http://example.org").Return("---", nil)
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(stream("---"), nil)
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).To(Equal(fmt.Errorf(ErrorUnmarshalForecast, "invalid character '-' in numeric literal")))
 			})
@@ -85,9 +96,9 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("the data received from the forecast lookup contains an invalid start time", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":"http://example.org"}}`, nil)
-				mockHttpClient.EXPECT().Get("http://example.org").Return(`{"properties":{"periods":[{"startTime":"invalid"}]}}`, nil)
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(stream(`{"properties":{"periods":[{"startTime":"invalid"}]}}`), nil)
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).To(Equal(fmt.Errorf(ErrorUnusualStartTime, "invalid time string")))
 			})
@@ -95,9 +106,9 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("the data received from the forecast lookup contains an invalid end time", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":"http://example.org"}}`, nil)
-				mockHttpClient.EXPECT().Get("http://example.org").Return(`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"invalid"}]}}`, nil)
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(stream(`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"invalid"}]}}`), nil)
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).To(Equal(fmt.Errorf(ErrorUnusualEndTime, "invalid time string")))
 			})
@@ -105,9 +116,9 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("the data received from the forecast lookup contains an invalid wind speed", func() {
 			It("should return an error", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":"http://example.org"}}`, nil)
-				mockHttpClient.EXPECT().Get("http://example.org").Return(`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "invalid"}]}}`, nil)
-				_, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(stream(`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "invalid"}]}}`), nil)
+				_, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).To(Equal(fmt.Errorf(ErrorUnusualWindSpeed, "Unexpected format for wind speed string")))
 			})
@@ -115,10 +126,10 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("the data received is valid and there is an upper and lower wind speed", func() {
 			It("should return a slice of weather forecasts", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":"http://example.org"}}`, nil)
-				mockHttpClient.EXPECT().Get("http://example.org").Return(
-					`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "4 to 8 mph", "shortForecast": "it will be sunny"}]}}`, nil)
-				predictions, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(
+					stream(`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "4 to 8 mph", "shortForecast": "it will be sunny"}]}}`), nil)
+				predictions, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(predictions[0].Wind.MinSpeed).To(Equal(4))
@@ -129,10 +140,10 @@ var _ = Describe("Weather forecast handler", func() {
 
 		When("the data received is valid and there is only one wind speed", func() {
 			It("should return a slice of weather forecasts", func() {
-				mockHttpClient.EXPECT().Get(gomock.Any()).Return(`{"properties":{"forecast":"http://example.org"}}`, nil)
-				mockHttpClient.EXPECT().Get("http://example.org").Return(
-					`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "5 mph", "shortForecast": "it will be sunny"}]}}`, nil)
-				predictions, err := mockFetcher.Fetch(structs.CoOrdinates{})
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(
+					stream(`{"properties":{"periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "5 mph", "shortForecast": "it will be sunny"}]}}`), nil)
+				predictions, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(predictions[0].Wind.MinSpeed).To(Equal(5))
@@ -140,5 +151,87 @@ var _ = Describe("Weather forecast handler", func() {
 				Expect(predictions[0].GetForecast()).To(Equal("it will be sunny"))
 			})
 		})
+
+		When("the forecast lookup includes NWS validity metadata", func() {
+			It("should attach the update and valid until times to each forecast", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(
+					stream(`{"properties":{"updateTime":"2022-01-01T11:30:00+00:00","validTimes":"2022-01-01T12:00:00+00:00/P1DT6H30M","periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "5 mph"}]}}`), nil)
+				predictions, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(predictions[0].Updated.Equal(time.Date(2022, 1, 1, 11, 30, 0, 0, time.UTC))).To(BeTrue())
+				Expect(predictions[0].ValidUntil.Equal(time.Date(2022, 1, 2, 18, 30, 0, 0, time.UTC))).To(BeTrue())
+			})
+		})
+
+		When("the forecast lookup gives times in a zone other than UTC", func() {
+			It("should keep their offsets", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(
+					stream(`{"properties":{"validTimes":"2022-01-01T06:00:00-06:00/PT12H","periods":[{"startTime":"2022-01-01T06:00:00-06:00", "endTime":"2022-01-01T18:00:00-06:00", "windSpeed": "5 mph"}]}}`), nil)
+				predictions, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(predictions[0].Start.Equal(time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC))).To(BeTrue())
+				Expect(predictions[0].End.Equal(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+				Expect(predictions[0].ValidUntil.Equal(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+			})
+		})
+
+		When("the forecast lookup has an unreadable validTimes interval", func() {
+			It("should still return the forecasts with no valid until time", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(stream(`{"properties":{"forecast":"http://example.org"}}`), nil)
+				mockHttpClient.EXPECT().Open(gomock.Any(), "http://example.org").Return(
+					stream(`{"properties":{"validTimes":"2022-01-01T12:00:00+00:00/P1Y","periods":[{"startTime":"2022-01-01T13:00:00", "endTime":"2022-01-01T18:00:00", "windSpeed": "5 mph"}]}}`), nil)
+				predictions, err := mockFetcher.Fetch(context.Background(), structs.CoOrdinates{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(predictions[0].ValidUntil.IsZero()).To(BeTrue())
+			})
+		})
+	})
+
+	Context("Finding the grid cell for a location", func() {
+		When("the lat/long lookup succeeds", func() {
+			It("should return the grid cell and its forecast resource", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), "https://api.weather.gov/points/41.87000,-87.62000").Return(
+					stream(`{"properties":{"gridId":"LOT","gridX":76,"gridY":73,"forecast":"http://example.org"}}`), nil)
+				grid, err := mockFetcher.Locate(context.Background(), structs.CoOrdinates{Latitude: 41.87, Longitude: -87.62})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(grid).To(Equal(structs.Grid{ID: "LOT", X: 76, Y: 73, Forecast: "http://example.org"}))
+				Expect(grid.Key()).To(Equal("lot/76,73"))
+			})
+		})
+
+		When("the lat/long lookup gives the nearest place", func() {
+			It("should name the grid cell after it", func() {
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(
+					stream(`{"properties":{"gridId":"LOT","gridX":76,"gridY":73,"forecast":"http://example.org","relativeLocation":{"properties":{"city":"Chicago","state":"IL"}}}}`), nil)
+				grid, err := mockFetcher.Locate(context.Background(), structs.CoOrdinates{Latitude: 41.87, Longitude: -87.62})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(grid.Location).To(Equal("Chicago, IL"))
+			})
+		})
+
+		When("the lat/long lookup is too large", func() {
+			It("should return the error as the cause", func() {
+				tooLarge := &httpClient.BodyTooLargeError{URL: "http://example.org", Limit: 10}
+				mockHttpClient.EXPECT().Open(gomock.Any(), gomock.Any()).Return(ioutil.NopCloser(io.MultiReader(strings.NewReader(`{"properties":`), iotest.ErrReader(tooLarge))), nil)
+				_, err := mockFetcher.Locate(context.Background(), structs.CoOrdinates{Latitude: 41.87, Longitude: -87.62})
+
+				Expect(httpClient.IsBodyTooLarge(err)).To(BeTrue())
+				Expect(err).To(Equal(fmt.Errorf(ErrorGetRequest, tooLarge)))
+			})
+		})
+
+		When("a forecast is requested for a grid cell with no forecast resource", func() {
+			It("should return an error", func() {
+				_, err := mockFetcher.Forecast(context.Background(), structs.Grid{ID: "LOT", X: 76, Y: 73})
+				Expect(err).To(Equal(errors.New(ErrorNoForecastResource)))
+			})
+		})
 	})
 })